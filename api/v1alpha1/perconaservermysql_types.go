@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"hash/fnv"
 	"strings"
+	"time"
 
 	"github.com/percona/percona-server-mysql-operator/pkg/platform"
+	"github.com/percona/percona-server-mysql-operator/pkg/schedule"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,6 +45,134 @@ type PerconaServerMySQLSpec struct {
 	MySQL                 MySQLSpec        `json:"mysql,omitempty"`
 	Orchestrator          OrchestratorSpec `json:"orchestrator,omitempty"`
 	PMM                   *PMMSpec         `json:"pmm,omitempty"`
+
+	// ReplicationSource makes the cluster a read only replica of another cluster
+	ReplicationSource *ReplicationSourceSpec `json:"replicationSource,omitempty"`
+	// Migration clones data from an external MySQL server and replicates from it until cutover
+	Migration *MigrationSpec `json:"migration,omitempty"`
+	// Binding writes connection details of the PerconaServerMySQLUser in binding.user to a Secret
+	Binding ClusterBindingSpec `json:"binding,omitempty"`
+	// PasswordRotation generates new passwords of system users periodically
+	PasswordRotation *PasswordRotationSpec `json:"passwordRotation,omitempty"`
+	// SecretsProvider is an external store of system user passwords
+	SecretsProvider *SecretsProviderSpec `json:"secretsProvider,omitempty"`
+}
+
+// SecretsProviderSpec configures an external store of system user passwords.
+// Passwords are read from the store periodically and copied to spec.secretsName,
+// changed passwords are applied the same way as passwords changed by hand.
+// Passwords are generated and written to the store if it has none. Changes of
+// spec.secretsName are overwritten, passwords must be changed in the store.
+type SecretsProviderSpec struct {
+	// RefreshInterval is how often passwords are read from the store, 1m by default
+	RefreshInterval metav1.Duration `json:"refreshInterval,omitempty"`
+	Vault           *VaultSpec      `json:"vault,omitempty"`
+}
+
+// VaultSpec describes a secret in KV version 2 secrets engine of HashiCorp Vault.
+// Keys of the secret are names of system users.
+type VaultSpec struct {
+	// Address of Vault, e.g. https://vault.vault.svc:8200
+	Address string `json:"address"`
+	// MountPath of the secrets engine, "secret" by default
+	MountPath string `json:"mountPath,omitempty"`
+	// Path of the secret in the secrets engine, e.g. mysql/cluster1
+	Path string `json:"path"`
+	// TokenSecret is the name of a Secret with Vault token in "token" key
+	TokenSecret string `json:"tokenSecret,omitempty"`
+	// KubernetesAuth logs in with the service account of the operator if tokenSecret is empty
+	KubernetesAuth *VaultKubernetesAuthSpec `json:"kubernetesAuth,omitempty"`
+	// CASecret is the name of a Secret with "ca.crt" which verifies the certificate of Vault
+	CASecret string `json:"caSecret,omitempty"`
+}
+
+type VaultKubernetesAuthSpec struct {
+	Role string `json:"role"`
+	// MountPath of the auth method, "kubernetes" by default
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// PasswordRotationSpec configures scheduled rotation of system user passwords.
+// New passwords are written to spec.secretsName and applied the same way as
+// passwords changed by hand, the old password keeps working until all
+// instances use the new one.
+type PasswordRotationSpec struct {
+	// Interval between rotations of the password of each user, 2160h (90 days) by default.
+	// The first rotation happens one interval after the cluster is created.
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// Schedule of rotations in cron format, e.g. "0 3 * * sun", times are in UTC.
+	// The password of each user is rotated at the first scheduled time after
+	// its last change. Can't be used with interval.
+	Schedule string `json:"schedule,omitempty"`
+	// Users whose passwords are rotated
+	// +kubebuilder:validation:MinItems=1
+	Users []RotatedUser `json:"users"`
+}
+
+// RotatedUser is a system user whose password can be rotated by the operator
+// +kubebuilder:validation:Enum=root;xtrabackup;monitor;clustercheck;operator;replication;orchestrator;orchestrator-api
+type RotatedUser SystemUser
+
+// ReplicationSourceSpec configures asynchronous replication from another cluster,
+// e.g. for a disaster recovery site. The primary replicates from one of the source
+// hosts and switches to another one if the current source fails. All instances
+// of the cluster are kept read only. System users are replicated from the source
+// cluster, so spec.secretsName must have the same passwords as the source cluster.
+type ReplicationSourceSpec struct {
+	// Hosts of the source cluster, e.g. exposed services of its MySQL pods
+	// +kubebuilder:validation:MinItems=1
+	Hosts []ReplicationSourceHost `json:"hosts"`
+	// CredentialsSecret is the name of the Secret with the password of the replication user
+	// of the source cluster in the "replication" key
+	CredentialsSecret string `json:"credentialsSecret"`
+	// Promote stops replication from the source cluster and makes the cluster writable
+	Promote bool `json:"promote,omitempty"`
+}
+
+type ReplicationSourceHost struct {
+	Host string `json:"host"`
+	Port int32  `json:"port,omitempty"`
+	// Weight is the priority of the host, the available host with the highest weight is used as the source
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// BindingSpec configures the Secret with connection details following the
+// Service Binding specification, so it can be projected into workloads by
+// Service Binding-aware platforms.
+type BindingSpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// SecretName is the name of the binding Secret, "<name>-binding" by default
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// ClusterBindingSpec configures the binding Secret of the cluster. Workloads get
+// credentials of an application user, passwords of system users are never
+// written to the binding Secret.
+type ClusterBindingSpec struct {
+	BindingSpec `json:",inline"`
+	// User is the name of a PerconaServerMySQLUser of the cluster, it's required if binding is enabled
+	User string `json:"user,omitempty"`
+}
+
+// MigrationSpec describes an external MySQL server which is migrated into the cluster.
+// The primary is cloned from the server with the clone plugin when the cluster is
+// created, other instances are cloned from the primary. After that the primary
+// replicates from the server using GTID auto-positioning and is kept read only
+// until cutover. Clone copies users too, so system users of spec.secretsName must
+// exist on the external server with the same passwords.
+type MigrationSpec struct {
+	Host string `json:"host"`
+	Port int32  `json:"port,omitempty"`
+	// CredentialsSecret is the name of the Secret with "username" and "password" keys of
+	// the user on the external server. The user needs BACKUP_ADMIN and REPLICATION SLAVE
+	// privileges and SELECT on performance_schema.
+	CredentialsSecret string `json:"credentialsSecret"`
+	// Cutover stops replication from the external server as soon as the primary has
+	// applied all its transactions and makes the primary writable. Writes to the
+	// external server should be stopped before the cutover.
+	Cutover bool `json:"cutover,omitempty"`
 }
 
 type ClusterType string
@@ -52,10 +182,19 @@ const (
 	ClusterTypeAsync ClusterType = "async"
 )
 
+type SemiSyncType string
+
+const (
+	SemiSyncTypeAfterSync   SemiSyncType = "after_sync"
+	SemiSyncTypeAfterCommit SemiSyncType = "after_commit"
+)
+
 type MySQLSpec struct {
-	ClusterType  ClusterType            `json:"clusterType,omitempty"`
-	SizeSemiSync intstr.IntOrString     `json:"sizeSemiSync,omitempty"`
-	SemiSyncType string                 `json:"semiSyncType,omitempty"`
+	ClusterType  ClusterType        `json:"clusterType,omitempty"`
+	SizeSemiSync intstr.IntOrString `json:"sizeSemiSync,omitempty"`
+	// SemiSyncType is the rpl_semi_sync_master_wait_point of the primary
+	// +kubebuilder:validation:Enum=after_sync;after_commit
+	SemiSyncType SemiSyncType           `json:"semiSyncType,omitempty"`
 	Expose       ServiceExposeTogglable `json:"expose,omitempty"`
 
 	Sidecars       []corev1.Container `json:"sidecars,omitempty"`
@@ -67,9 +206,77 @@ type MySQLSpec struct {
 	PrimaryServiceType  corev1.ServiceType `json:"primaryServiceType,omitempty"`
 	ReplicasServiceType corev1.ServiceType `json:"replicasServiceType,omitempty"`
 
+	// DeletePVCOnScaleDown deletes PVCs of the pods removed by scale down
+	DeletePVCOnScaleDown bool `json:"deletePVCOnScaleDown,omitempty"`
+
+	// PromotionRules are registered in Orchestrator for each MySQL pod.
+	// The first matching rule is applied, pods without matching rules are neutral.
+	PromotionRules []PromotionRule `json:"promotionRules,omitempty"`
+
+	// Heartbeat enables event on the primary which updates meta.heartbeat table every second.
+	// Heartbeat is used by Orchestrator and readiness probe to measure replication lag.
+	Heartbeat bool `json:"heartbeat,omitempty"`
+	// MaxReplicationLag in seconds. Replicas with higher lag are not ready. Requires heartbeat.
+	MaxReplicationLag int64 `json:"maxReplicationLag,omitempty"`
+
+	// ReplicaPools are additional StatefulSets of replicas which replicate from the primary.
+	// Instances of replica pools are never promoted to primary.
+	ReplicaPools []ReplicaPoolSpec `json:"replicaPools,omitempty"`
+
+	PodSpec `json:",inline"`
+}
+
+// ReplicaPoolSpec defines a pool of replicas with its own StatefulSet and Service.
+// Image, probes and security contexts which are not set are inherited from spec.mysql.
+// Pool pods use configuration of spec.mysql.
+type ReplicaPoolSpec struct {
+	// Name of the pool, pods are named <cluster>-mysql-<name>-<ordinal>. "primary" and "unready" are reserved
+	// +kubebuilder:validation:Pattern=`^[a-z]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// SourceDelay is the replication delay of the pool pods in seconds
+	// +kubebuilder:validation:Minimum=0
+	SourceDelay int32 `json:"sourceDelay,omitempty"`
+	// Recovery detaches the pool from the cluster to recover data from delayed replicas
+	Recovery *ReplicaPoolRecovery `json:"recovery,omitempty"`
+
 	PodSpec `json:",inline"`
 }
 
+// ReplicaPoolRecovery is used to recover data from a delayed replica pool, e.g.
+// after a table was dropped by mistake. Pods of the pool are removed from
+// Orchestrator, replication delay is reset and replication stops right before
+// the first transaction of StopBeforeGTIDs. Replication isn't started on restart
+// of the pool pods. Removing recovery doesn't attach the pool back, the pool
+// should be removed or renamed to be cloned from scratch.
+type ReplicaPoolRecovery struct {
+	// StopBeforeGTIDs is the GTID set of the bad transactions, e.g. 3E11FA47-71CA-11E1-9E33-C80AA9429562:23
+	// +kubebuilder:validation:MinLength=1
+	StopBeforeGTIDs string `json:"stopBeforeGTIDs"`
+	// Promote resets replication on the pool pods when replication is stopped
+	// and makes them standalone writable instances
+	Promote bool `json:"promote,omitempty"`
+}
+
+type PromotionRuleType string
+
+const (
+	PromotionRulePrefer    PromotionRuleType = "prefer"
+	PromotionRuleNeutral   PromotionRuleType = "neutral"
+	PromotionRulePreferNot PromotionRuleType = "prefer_not"
+	PromotionRuleMustNot   PromotionRuleType = "must_not"
+)
+
+type PromotionRule struct {
+	// +kubebuilder:validation:Enum=prefer;neutral;prefer_not;must_not
+	Rule PromotionRuleType `json:"rule"`
+	// Ordinals of MySQL pods the rule is applied to
+	Ordinals []int `json:"ordinals,omitempty"`
+	// NodeSelector matches labels of nodes the MySQL pods are running on.
+	// Nodes are cluster-scoped, the namespaced operator requires the ClusterRole
+	// from deploy/node-rbac.yaml to read them.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
 type SidecarPVC struct {
 	Name string `json:"name"`
 
@@ -79,6 +286,10 @@ type SidecarPVC struct {
 type OrchestratorSpec struct {
 	Expose ServiceExpose `json:"expose,omitempty"`
 
+	// TLSEnabled serves Orchestrator API and UI over HTTPS using certificate from
+	// spec.sslSecretName. Certificate must be valid for *.<cluster>-orc.<namespace>.
+	TLSEnabled bool `json:"tlsEnabled,omitempty"`
+
 	PodSpec `json:",inline"`
 }
 
@@ -186,11 +397,73 @@ type StatefulAppStatus struct {
 // PerconaServerMySQLStatus defines the observed state of PerconaServerMySQL
 type PerconaServerMySQLStatus struct { // INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	MySQL        StatefulAppStatus `json:"mysql,omitempty"`
-	Orchestrator StatefulAppStatus `json:"orchestrator,omitempty"`
-	State        StatefulAppState  `json:"state,omitempty"`
+	MySQL        StatefulAppStatus  `json:"mysql,omitempty"`
+	Orchestrator StatefulAppStatus  `json:"orchestrator,omitempty"`
+	State        StatefulAppState   `json:"state,omitempty"`
+	Conditions   []metav1.Condition `json:"conditions,omitempty"`
+	// SemiSyncReplicas is the number of semi-sync replicas connected to the primary
+	SemiSyncReplicas int32 `json:"semiSyncReplicas,omitempty"`
+	// ReplicaPools is the status of replica pools by pool name
+	ReplicaPools map[string]ReplicaPoolStatus `json:"replicaPools,omitempty"`
+	// Migration is the status of spec.migration
+	Migration *MigrationStatus `json:"migration,omitempty"`
+	// Binding is the Secret with connection details if spec.binding is enabled
+	Binding *corev1.LocalObjectReference `json:"binding,omitempty"`
+	// PasswordRotations is the time of the last password change by system user
+	PasswordRotations map[string]metav1.Time `json:"passwordRotations,omitempty"`
+}
+
+type MigrationState string
+
+const (
+	// MigrationStateCloning means that the primary isn't cloned from the external server yet
+	MigrationStateCloning MigrationState = "cloning"
+	// MigrationStateReplicating means that the primary replicates from the external server
+	MigrationStateReplicating MigrationState = "replicating"
+	// MigrationStateCuttingOver means that cutover waits for the primary to apply
+	// all transactions executed on the external server
+	MigrationStateCuttingOver MigrationState = "cuttingOver"
+	// MigrationStateCompleted means that replication from the external server
+	// is removed and the primary is writable
+	MigrationStateCompleted MigrationState = "completed"
+)
+
+type MigrationStatus struct {
+	State MigrationState `json:"state,omitempty"`
+	// SecondsBehindSource is the replication lag of the primary behind the external server
+	SecondsBehindSource int64 `json:"secondsBehindSource,omitempty"`
+	// GTIDExecuted is gtid_executed of the primary at cutover, it can be compared
+	// with gtid_executed of the external server to verify the migration
+	GTIDExecuted string `json:"gtidExecuted,omitempty"`
 }
 
+type ReplicaPoolRecoveryState string
+
+const (
+	// RecoveryStateApplying means that replication is running until spec.mysql.replicaPools[].recovery.stopBeforeGTIDs
+	RecoveryStateApplying ReplicaPoolRecoveryState = "applying"
+	// RecoveryStateStopped means that replication is stopped before spec.mysql.replicaPools[].recovery.stopBeforeGTIDs
+	RecoveryStateStopped ReplicaPoolRecoveryState = "stopped"
+	// RecoveryStatePromoted means that pods of the pool are standalone writable instances
+	RecoveryStatePromoted ReplicaPoolRecoveryState = "promoted"
+	// RecoveryStateFailed means that transactions from spec.mysql.replicaPools[].recovery.stopBeforeGTIDs
+	// are already applied or replication failed
+	RecoveryStateFailed ReplicaPoolRecoveryState = "failed"
+)
+
+type ReplicaPoolStatus struct {
+	StatefulAppStatus `json:",inline"`
+	// Recovery is the state of spec.mysql.replicaPools[].recovery
+	Recovery ReplicaPoolRecoveryState `json:"recovery,omitempty"`
+}
+
+const (
+	// ConditionSplitBrain is true if there is a writable MySQL instance except the primary
+	ConditionSplitBrain = "SplitBrain"
+	// ConditionErrantTransactions is true if there is a replica with transactions which don't exist on the primary
+	ConditionErrantTransactions = "ErrantTransactions"
+)
+
 // PerconaServerMySQL is the Schema for the perconaservermysqls API
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
@@ -229,6 +502,9 @@ const (
 	UserReplication  SystemUser = "replication"
 	UserOrchestrator SystemUser = "orchestrator"
 	UserPMMServer    SystemUser = "pmmserver"
+
+	// UserOrchestratorAPI is the user of Orchestrator API and UI
+	UserOrchestratorAPI SystemUser = "orchestrator-api"
 )
 
 func (cr *PerconaServerMySQL) MySQLSpec() *MySQLSpec {
@@ -243,11 +519,101 @@ func (cr *PerconaServerMySQL) OrchestratorSpec() *OrchestratorSpec {
 	return &cr.Spec.Orchestrator
 }
 
+// IsReplicaCluster returns true if the cluster replicates from spec.replicationSource and isn't promoted
+func (cr *PerconaServerMySQL) IsReplicaCluster() bool {
+	return cr.Spec.ReplicationSource != nil && !cr.Spec.ReplicationSource.Promote
+}
+
+// IsMigrating returns true if spec.migration is set and its cutover isn't completed
+func (cr *PerconaServerMySQL) IsMigrating() bool {
+	if cr.Spec.Migration == nil {
+		return false
+	}
+
+	return cr.Status.Migration == nil || cr.Status.Migration.State != MigrationStateCompleted
+}
+
 func (cr *PerconaServerMySQL) CheckNSetDefaults(serverVersion *platform.ServerVersion) error {
 	if cr.Spec.MySQL.SizeSemiSync.IntVal >= cr.Spec.MySQL.Size {
 		return errors.New("mysql.sizeSemiSync can't be greater than or equal to mysql.size")
 	}
 
+	if cr.Spec.MySQL.MaxReplicationLag > 0 && !cr.Spec.MySQL.Heartbeat {
+		return errors.New("mysql.maxReplicationLag requires mysql.heartbeat to be enabled")
+	}
+
+	if rs := cr.Spec.ReplicationSource; rs != nil {
+		if rs.CredentialsSecret == "" {
+			return errors.New("replicationSource.credentialsSecret is required")
+		}
+		for i := range rs.Hosts {
+			if rs.Hosts[i].Port == 0 {
+				rs.Hosts[i].Port = 3306
+			}
+			if rs.Hosts[i].Weight == 0 {
+				rs.Hosts[i].Weight = 50
+			}
+		}
+	}
+
+	if m := cr.Spec.Migration; m != nil {
+		if cr.Spec.ReplicationSource != nil {
+			return errors.New("migration and replicationSource can't be used together")
+		}
+		if m.CredentialsSecret == "" {
+			return errors.New("migration.credentialsSecret is required")
+		}
+		if m.Port == 0 {
+			m.Port = 3306
+		}
+	}
+
+	if cr.Spec.Binding.Enabled && cr.Spec.Binding.SecretName == "" {
+		cr.Spec.Binding.SecretName = cr.Name + "-binding"
+	}
+
+	if pr := cr.Spec.PasswordRotation; pr != nil {
+		if cr.Spec.ReplicationSource != nil {
+			return errors.New("passwordRotation can't be used with replicationSource, passwords are rotated on the source cluster")
+		}
+		if pr.Schedule != "" {
+			if pr.Interval.Duration != 0 {
+				return errors.New("passwordRotation.interval and passwordRotation.schedule can't be used together")
+			}
+			if _, err := schedule.Parse(pr.Schedule); err != nil {
+				return errors.Wrap(err, "invalid passwordRotation.schedule")
+			}
+		} else if pr.Interval.Duration == 0 {
+			pr.Interval.Duration = 90 * 24 * time.Hour
+		}
+	}
+
+	if sp := cr.Spec.SecretsProvider; sp != nil {
+		v := sp.Vault
+		if v == nil {
+			return errors.New("secretsProvider.vault is required")
+		}
+		if v.Address == "" || v.Path == "" {
+			return errors.New("secretsProvider.vault.address and secretsProvider.vault.path are required")
+		}
+		if v.TokenSecret == "" && v.KubernetesAuth == nil {
+			return errors.New("secretsProvider.vault.tokenSecret or secretsProvider.vault.kubernetesAuth is required")
+		}
+		if v.MountPath == "" {
+			v.MountPath = "secret"
+		}
+		if v.KubernetesAuth != nil && v.KubernetesAuth.MountPath == "" {
+			v.KubernetesAuth.MountPath = "kubernetes"
+		}
+		if sp.RefreshInterval.Duration == 0 {
+			sp.RefreshInterval.Duration = time.Minute
+		}
+	}
+
+	if cr.Spec.MySQL.SemiSyncType == "" {
+		cr.Spec.MySQL.SemiSyncType = SemiSyncTypeAfterSync
+	}
+
 	if cr.Spec.MySQL.StartupProbe.InitialDelaySeconds == 0 {
 		cr.Spec.MySQL.StartupProbe.InitialDelaySeconds = 15
 	}
@@ -324,6 +690,22 @@ func (cr *PerconaServerMySQL) CheckNSetDefaults(serverVersion *platform.ServerVe
 	cr.Spec.MySQL.reconcileAffinityOpts()
 	cr.Spec.Orchestrator.reconcileAffinityOpts()
 
+	pools := make(map[string]struct{}, len(cr.Spec.MySQL.ReplicaPools))
+	for i := range cr.Spec.MySQL.ReplicaPools {
+		pool := &cr.Spec.MySQL.ReplicaPools[i]
+		if _, ok := pools[pool.Name]; ok {
+			return errors.Errorf("mysql.replicaPools: duplicate pool %s", pool.Name)
+		}
+		for _, name := range reservedPoolNames {
+			if pool.Name == name {
+				return errors.Errorf("mysql.replicaPools: %s is a reserved name", pool.Name)
+			}
+		}
+		pools[pool.Name] = struct{}{}
+
+		pool.setDefaults(&cr.Spec.MySQL.PodSpec)
+	}
+
 	if oSize := int(cr.Spec.Orchestrator.Size); (oSize < 3 || oSize%2 == 0) && !cr.Spec.AllowUnsafeConfig {
 		return errors.New("Orchestrator size must be 3 or greater and an odd number for raft setup")
 	}
@@ -331,6 +713,57 @@ func (cr *PerconaServerMySQL) CheckNSetDefaults(serverVersion *platform.ServerVe
 	return nil
 }
 
+// reservedPoolNames can't be used by replica pools, services of the pools
+// would overwrite services of the cluster with the same name
+var reservedPoolNames = []string{"primary", "unready"}
+
+// setDefaults inherits options of the pool which are not set from spec.mysql
+func (p *ReplicaPoolSpec) setDefaults(mysql *PodSpec) {
+	if p.Image == "" {
+		p.Image = mysql.Image
+	}
+	if p.ImagePullPolicy == "" {
+		p.ImagePullPolicy = mysql.ImagePullPolicy
+	}
+	if len(p.ImagePullSecrets) == 0 {
+		p.ImagePullSecrets = mysql.ImagePullSecrets
+	}
+	if p.PodSecurityContext == nil {
+		p.PodSecurityContext = mysql.PodSecurityContext
+	}
+	if p.ContainerSecurityContext == nil {
+		p.ContainerSecurityContext = mysql.ContainerSecurityContext
+	}
+	if p.VolumeSpec == nil {
+		p.VolumeSpec = mysql.VolumeSpec.DeepCopy()
+	}
+	p.VolumeSpec = reconcileVol(p.VolumeSpec)
+
+	inheritProbe(&p.StartupProbe, mysql.StartupProbe)
+	inheritProbe(&p.LivenessProbe, mysql.LivenessProbe)
+	inheritProbe(&p.ReadinessProbe, mysql.ReadinessProbe)
+
+	p.reconcileAffinityOpts()
+}
+
+func inheritProbe(p *corev1.Probe, from corev1.Probe) {
+	if p.InitialDelaySeconds == 0 {
+		p.InitialDelaySeconds = from.InitialDelaySeconds
+	}
+	if p.PeriodSeconds == 0 {
+		p.PeriodSeconds = from.PeriodSeconds
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = from.FailureThreshold
+	}
+	if p.SuccessThreshold == 0 {
+		p.SuccessThreshold = from.SuccessThreshold
+	}
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = from.TimeoutSeconds
+	}
+}
+
 func reconcileVol(v *VolumeSpec) *VolumeSpec {
 	if v == nil {
 		v = &VolumeSpec{}
@@ -426,16 +859,22 @@ const (
 	AnnotationSpecHash   AnnotationKey = "percona.com/last-applied-spec"
 	AnnotationSecretHash AnnotationKey = "percona.com/last-applied-secret"
 	AnnotationConfigHash AnnotationKey = "percona.com/last-applied-config"
+	AnnotationTLSHash    AnnotationKey = "percona.com/last-applied-tls"
+
+	// AnnotationFailoverNotice is the last failover notice received by the failover hook
+	AnnotationFailoverNotice AnnotationKey = "percona.com/last-failover-notice"
 )
 
 const (
-	NameLabel         = "app.kubernetes.io/name"
-	InstanceLabel     = "app.kubernetes.io/instance"
-	ManagedByLabel    = "app.kubernetes.io/managed-by"
-	PartOfLabel       = "app.kubernetes.io/part-of"
-	ComponentLabel    = "app.kubernetes.io/component"
-	MySQLPrimaryLabel = "mysql.percona.com/primary"
-	ExposedLabel      = "percona.com/exposed"
+	NameLabel             = "app.kubernetes.io/name"
+	InstanceLabel         = "app.kubernetes.io/instance"
+	ManagedByLabel        = "app.kubernetes.io/managed-by"
+	PartOfLabel           = "app.kubernetes.io/part-of"
+	ComponentLabel        = "app.kubernetes.io/component"
+	MySQLPrimaryLabel     = "mysql.percona.com/primary"
+	MySQLFencedLabel      = "mysql.percona.com/fenced"
+	MySQLReplicaPoolLabel = "mysql.percona.com/replica-pool"
+	ExposedLabel          = "percona.com/exposed"
 )
 
 func (cr *PerconaServerMySQL) Labels() map[string]string {
@@ -462,8 +901,18 @@ func GetClusterNameFromObject(obj client.Object) (string, error) {
 
 // ClusterHash returns FNV hash of the CustomResource UID
 func (cr *PerconaServerMySQL) ClusterHash() string {
+	return serverIDHash(string(cr.UID))
+}
+
+// ReplicaPoolHash returns FNV hash of the CustomResource UID and the pool name.
+// It's used instead of ClusterHash in server ids of the pool pods.
+func (cr *PerconaServerMySQL) ReplicaPoolHash(pool string) string {
+	return serverIDHash(string(cr.UID) + "/" + pool)
+}
+
+func serverIDHash(s string) string {
 	serverIDHash := fnv.New32()
-	serverIDHash.Write([]byte(string(cr.UID)))
+	serverIDHash.Write([]byte(s))
 
 	// We use only first 7 digits to give a space for pod number which is
 	// appended to all server ids. If we don't do this, it can cause a
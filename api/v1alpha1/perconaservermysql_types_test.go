@@ -0,0 +1,42 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/percona/percona-server-mysql-operator/pkg/platform"
+)
+
+func TestCheckNSetDefaultsReplicaPools(t *testing.T) {
+	tests := []struct {
+		name  string
+		pools []string
+		valid bool
+	}{
+		{"no pools", nil, true},
+		{"pools", []string{"delayed", "reporting"}, true},
+		{"duplicate pool", []string{"delayed", "delayed"}, false},
+		{"primary service", []string{"primary"}, false},
+		{"unready service", []string{"delayed", "unready"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &PerconaServerMySQL{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "ns"}}
+			cr.Spec.MySQL.Size = 3
+			cr.Spec.Orchestrator.Size = 3
+			for _, pool := range tt.pools {
+				cr.Spec.MySQL.ReplicaPools = append(cr.Spec.MySQL.ReplicaPools, ReplicaPoolSpec{Name: pool})
+			}
+
+			err := cr.CheckNSetDefaults(&platform.ServerVersion{Platform: platform.PlatformKubernetes})
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
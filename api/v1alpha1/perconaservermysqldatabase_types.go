@@ -0,0 +1,120 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type DatabaseDeletionPolicy string
+
+const (
+	// DatabaseDeletionPolicyRetain keeps the database when PerconaServerMySQLDatabase is deleted
+	DatabaseDeletionPolicyRetain DatabaseDeletionPolicy = "retain"
+	// DatabaseDeletionPolicyDrop drops the database when PerconaServerMySQLDatabase is deleted
+	DatabaseDeletionPolicyDrop DatabaseDeletionPolicy = "drop"
+)
+
+// PerconaServerMySQLDatabaseSpec defines the desired state of PerconaServerMySQLDatabase
+type PerconaServerMySQLDatabaseSpec struct {
+	// ClusterName is the name of PerconaServerMySQL in the same namespace
+	ClusterName string `json:"clusterName"`
+	// Name of the database, metadata.name is used if it's empty
+	// +kubebuilder:validation:MaxLength=64
+	Name string `json:"name,omitempty"`
+	// Charset is the default character set of the database, e.g. utf8mb4
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_]+$`
+	Charset string `json:"charset,omitempty"`
+	// Collation is the default collation of the database, e.g. utf8mb4_0900_ai_ci
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_]+$`
+	Collation string `json:"collation,omitempty"`
+	// +kubebuilder:validation:Enum=retain;drop
+	DeletionPolicy DatabaseDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+type DatabaseState string
+
+const (
+	// DatabaseStatePending means that the cluster isn't ready to create the database
+	DatabaseStatePending DatabaseState = "pending"
+	DatabaseStateReady   DatabaseState = "ready"
+	DatabaseStateError   DatabaseState = "error"
+)
+
+// PerconaServerMySQLDatabaseStatus defines the observed state of PerconaServerMySQLDatabase
+type PerconaServerMySQLDatabaseStatus struct {
+	State   DatabaseState `json:"state,omitempty"`
+	Message string        `json:"message,omitempty"`
+	// Name of the created database, it's dropped on deletion if deletionPolicy is drop
+	Name string `json:"name,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=".spec.clusterName"
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=".status.state"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:resource:scope=Namespaced
+//+kubebuilder:resource:shortName=ps-db
+
+// PerconaServerMySQLDatabase is the Schema for the perconaservermysqldatabases API
+type PerconaServerMySQLDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PerconaServerMySQLDatabaseSpec   `json:"spec,omitempty"`
+	Status PerconaServerMySQLDatabaseStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PerconaServerMySQLDatabaseList contains a list of PerconaServerMySQLDatabase
+type PerconaServerMySQLDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PerconaServerMySQLDatabase `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PerconaServerMySQLDatabase{}, &PerconaServerMySQLDatabaseList{})
+}
+
+// systemDatabases can't be managed by PerconaServerMySQLDatabase. The meta
+// database is created by the operator for the replication heartbeat.
+var systemDatabases = []string{"mysql", "sys", "information_schema", "performance_schema", "meta"}
+
+func (d *PerconaServerMySQLDatabase) CheckNSetDefaults() error {
+	if d.Spec.ClusterName == "" {
+		return errors.New("clusterName is required")
+	}
+
+	if d.Spec.Name == "" {
+		d.Spec.Name = d.Name
+	}
+	for _, name := range systemDatabases {
+		if d.Spec.Name == name {
+			return errors.Errorf("%s is a system database", d.Spec.Name)
+		}
+	}
+
+	if d.Spec.DeletionPolicy == "" {
+		d.Spec.DeletionPolicy = DatabaseDeletionPolicyRetain
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PerconaServerMySQLUserSpec defines the desired state of PerconaServerMySQLUser
+type PerconaServerMySQLUserSpec struct {
+	// ClusterName is the name of PerconaServerMySQL in the same namespace
+	ClusterName string `json:"clusterName"`
+	// Name of the MySQL user, metadata.name is used if it's empty
+	// +kubebuilder:validation:MaxLength=32
+	Name string `json:"name,omitempty"`
+	// Hosts the user can connect from, "%" by default
+	Hosts []string `json:"hosts,omitempty"`
+	// PasswordSecretRef is the key of the Secret with the password of the user.
+	// When the password is changed, the old one keeps working for retainOldPassword.
+	PasswordSecretRef corev1.SecretKeySelector `json:"passwordSecretRef"`
+	// RetainOldPassword is how long the old password keeps working after
+	// the password is changed, it's discarded in the next reconcile by default
+	RetainOldPassword *metav1.Duration `json:"retainOldPassword,omitempty"`
+	// Databases are created if they don't exist, they are never dropped by the operator
+	Databases []string           `json:"databases,omitempty"`
+	Grants    []UserGrant        `json:"grants,omitempty"`
+	Limits    UserResourceLimits `json:"limits,omitempty"`
+	// Binding writes connection details of the user to a Secret,
+	// the first database of spec.databases is used as the database
+	Binding BindingSpec `json:"binding,omitempty"`
+}
+
+// Privilege is a static privilege, e.g. SELECT or ALL, or a dynamic one, e.g. XA_RECOVER_ADMIN
+// +kubebuilder:validation:Pattern=`^[A-Za-z_ ]+$`
+type Privilege string
+
+// UserGrant is granted by the operator, so it can't include privileges the
+// operator doesn't hold, e.g. FILE or SHUTDOWN
+type UserGrant struct {
+	// +kubebuilder:validation:MinItems=1
+	Privileges []Privilege `json:"privileges"`
+	// Database the privileges are granted on, "*" means all databases
+	Database string `json:"database"`
+	// Table the privileges are granted on, "*" by default
+	Table           string `json:"table,omitempty"`
+	WithGrantOption bool   `json:"withGrantOption,omitempty"`
+}
+
+// UserResourceLimits are account resource limits, 0 means no limit
+type UserResourceLimits struct {
+	// +kubebuilder:validation:Minimum=0
+	MaxQueriesPerHour int32 `json:"maxQueriesPerHour,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MaxUpdatesPerHour int32 `json:"maxUpdatesPerHour,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MaxConnectionsPerHour int32 `json:"maxConnectionsPerHour,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MaxUserConnections int32 `json:"maxUserConnections,omitempty"`
+}
+
+type UserState string
+
+const (
+	// UserStatePending means that the cluster isn't ready to apply the user
+	UserStatePending UserState = "pending"
+	UserStateReady   UserState = "ready"
+	UserStateError   UserState = "error"
+)
+
+// PerconaServerMySQLUserStatus defines the observed state of PerconaServerMySQLUser
+type PerconaServerMySQLUserStatus struct {
+	State   UserState `json:"state,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// Hosts the user is created on, user is dropped from hosts which are removed from spec.hosts
+	Hosts []string `json:"hosts,omitempty"`
+	// Grants applied to the user, grants which are removed from spec.grants are revoked
+	Grants []UserGrant `json:"grants,omitempty"`
+	// PasswordHash is the hash of the applied password
+	PasswordHash string `json:"passwordHash,omitempty"`
+	// PasswordChanged is set when the password is changed and the old one is retained
+	PasswordChanged *metav1.Time `json:"passwordChanged,omitempty"`
+	// Binding is the Secret with connection details if spec.binding is enabled
+	Binding *corev1.LocalObjectReference `json:"binding,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=".spec.clusterName"
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=".status.state"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:resource:scope=Namespaced
+//+kubebuilder:resource:shortName=ps-user
+
+// PerconaServerMySQLUser is the Schema for the perconaservermysqlusers API
+type PerconaServerMySQLUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PerconaServerMySQLUserSpec   `json:"spec,omitempty"`
+	Status PerconaServerMySQLUserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PerconaServerMySQLUserList contains a list of PerconaServerMySQLUser
+type PerconaServerMySQLUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PerconaServerMySQLUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PerconaServerMySQLUser{}, &PerconaServerMySQLUserList{})
+}
+
+// systemUsers are managed by PerconaServerMySQL and can't be managed by PerconaServerMySQLUser
+var systemUsers = []SystemUser{
+	UserRoot,
+	UserXtraBackup,
+	UserMonitor,
+	UserClusterCheck,
+	UserProxyAdmin,
+	UserOperator,
+	UserReplication,
+	UserOrchestrator,
+	UserPMMServer,
+}
+
+func (u *PerconaServerMySQLUser) CheckNSetDefaults() error {
+	if u.Spec.ClusterName == "" {
+		return errors.New("clusterName is required")
+	}
+
+	if u.Spec.Name == "" {
+		u.Spec.Name = u.Name
+	}
+	for _, su := range systemUsers {
+		if u.Spec.Name == string(su) {
+			return errors.Errorf("%s is a system user", u.Spec.Name)
+		}
+	}
+	if strings.HasPrefix(u.Spec.Name, "mysql.") {
+		return errors.Errorf("%s is a reserved account", u.Spec.Name)
+	}
+
+	if len(u.Spec.Hosts) == 0 {
+		u.Spec.Hosts = []string{"%"}
+	}
+
+	if u.Spec.Binding.Enabled && u.Spec.Binding.SecretName == "" {
+		u.Spec.Binding.SecretName = u.Name + "-binding"
+	}
+
+	if u.Spec.PasswordSecretRef.Key == "" {
+		u.Spec.PasswordSecretRef.Key = "password"
+	}
+
+	for i := range u.Spec.Grants {
+		if u.Spec.Grants[i].Table == "" {
+			u.Spec.Grants[i].Table = "*"
+		}
+	}
+
+	return nil
+}
@@ -23,10 +23,72 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BindingSpec) DeepCopyInto(out *BindingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingSpec.
+func (in *BindingSpec) DeepCopy() *BindingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BindingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBindingSpec) DeepCopyInto(out *ClusterBindingSpec) {
+	*out = *in
+	out.BindingSpec = in.BindingSpec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBindingSpec.
+func (in *ClusterBindingSpec) DeepCopy() *ClusterBindingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBindingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationSpec.
+func (in *MigrationSpec) DeepCopy() *MigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStatus.
+func (in *MigrationStatus) DeepCopy() *MigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MySQLSpec) DeepCopyInto(out *MySQLSpec) {
 	*out = *in
@@ -53,6 +115,20 @@ func (in *MySQLSpec) DeepCopyInto(out *MySQLSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PromotionRules != nil {
+		in, out := &in.PromotionRules, &out.PromotionRules
+		*out = make([]PromotionRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicaPools != nil {
+		in, out := &in.ReplicaPools, &out.ReplicaPools
+		*out = make([]ReplicaPoolSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.PodSpec.DeepCopyInto(&out.PodSpec)
 }
 
@@ -109,13 +185,34 @@ func (in *PMMSpec) DeepCopy() *PMMSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordRotationSpec) DeepCopyInto(out *PasswordRotationSpec) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]RotatedUser, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordRotationSpec.
+func (in *PasswordRotationSpec) DeepCopy() *PasswordRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PerconaServerMySQL) DeepCopyInto(out *PerconaServerMySQL) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQL.
@@ -225,6 +322,95 @@ func (in *PerconaServerMySQLBackupStatus) DeepCopy() *PerconaServerMySQLBackupSt
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLDatabase) DeepCopyInto(out *PerconaServerMySQLDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLDatabase.
+func (in *PerconaServerMySQLDatabase) DeepCopy() *PerconaServerMySQLDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PerconaServerMySQLDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLDatabaseList) DeepCopyInto(out *PerconaServerMySQLDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PerconaServerMySQLDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLDatabaseList.
+func (in *PerconaServerMySQLDatabaseList) DeepCopy() *PerconaServerMySQLDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PerconaServerMySQLDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLDatabaseSpec) DeepCopyInto(out *PerconaServerMySQLDatabaseSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLDatabaseSpec.
+func (in *PerconaServerMySQLDatabaseSpec) DeepCopy() *PerconaServerMySQLDatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLDatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLDatabaseStatus) DeepCopyInto(out *PerconaServerMySQLDatabaseStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLDatabaseStatus.
+func (in *PerconaServerMySQLDatabaseStatus) DeepCopy() *PerconaServerMySQLDatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLDatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PerconaServerMySQLList) DeepCopyInto(out *PerconaServerMySQLList) {
 	*out = *in
@@ -356,6 +542,27 @@ func (in *PerconaServerMySQLSpec) DeepCopyInto(out *PerconaServerMySQLSpec) {
 		*out = new(PMMSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ReplicationSource != nil {
+		in, out := &in.ReplicationSource, &out.ReplicationSource
+		*out = new(ReplicationSourceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationSpec)
+		**out = **in
+	}
+	out.Binding = in.Binding
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(PasswordRotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretsProvider != nil {
+		in, out := &in.SecretsProvider, &out.SecretsProvider
+		*out = new(SecretsProviderSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLSpec.
@@ -373,6 +580,37 @@ func (in *PerconaServerMySQLStatus) DeepCopyInto(out *PerconaServerMySQLStatus)
 	*out = *in
 	out.MySQL = in.MySQL
 	out.Orchestrator = in.Orchestrator
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicaPools != nil {
+		in, out := &in.ReplicaPools, &out.ReplicaPools
+		*out = make(map[string]ReplicaPoolStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationStatus)
+		**out = **in
+	}
+	if in.Binding != nil {
+		in, out := &in.Binding, &out.Binding
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.PasswordRotations != nil {
+		in, out := &in.PasswordRotations, &out.PasswordRotations
+		*out = make(map[string]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLStatus.
@@ -385,6 +623,141 @@ func (in *PerconaServerMySQLStatus) DeepCopy() *PerconaServerMySQLStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLUser) DeepCopyInto(out *PerconaServerMySQLUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLUser.
+func (in *PerconaServerMySQLUser) DeepCopy() *PerconaServerMySQLUser {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PerconaServerMySQLUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLUserList) DeepCopyInto(out *PerconaServerMySQLUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PerconaServerMySQLUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLUserList.
+func (in *PerconaServerMySQLUserList) DeepCopy() *PerconaServerMySQLUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PerconaServerMySQLUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLUserSpec) DeepCopyInto(out *PerconaServerMySQLUserSpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.PasswordSecretRef.DeepCopyInto(&out.PasswordSecretRef)
+	if in.RetainOldPassword != nil {
+		in, out := &in.RetainOldPassword, &out.RetainOldPassword
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]UserGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Limits = in.Limits
+	out.Binding = in.Binding
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLUserSpec.
+func (in *PerconaServerMySQLUserSpec) DeepCopy() *PerconaServerMySQLUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerconaServerMySQLUserStatus) DeepCopyInto(out *PerconaServerMySQLUserStatus) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]UserGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PasswordChanged != nil {
+		in, out := &in.PasswordChanged, &out.PasswordChanged
+		*out = (*in).DeepCopy()
+	}
+	if in.Binding != nil {
+		in, out := &in.Binding, &out.Binding
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerconaServerMySQLUserStatus.
+func (in *PerconaServerMySQLUserStatus) DeepCopy() *PerconaServerMySQLUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PerconaServerMySQLUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodAffinity) DeepCopyInto(out *PodAffinity) {
 	*out = *in
@@ -534,6 +907,141 @@ func (in *PodSpec) DeepCopy() *PodSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionRule) DeepCopyInto(out *PromotionRule) {
+	*out = *in
+	if in.Ordinals != nil {
+		in, out := &in.Ordinals, &out.Ordinals
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionRule.
+func (in *PromotionRule) DeepCopy() *PromotionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaPoolRecovery) DeepCopyInto(out *ReplicaPoolRecovery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaPoolRecovery.
+func (in *ReplicaPoolRecovery) DeepCopy() *ReplicaPoolRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaPoolRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaPoolSpec) DeepCopyInto(out *ReplicaPoolSpec) {
+	*out = *in
+	if in.Recovery != nil {
+		in, out := &in.Recovery, &out.Recovery
+		*out = new(ReplicaPoolRecovery)
+		**out = **in
+	}
+	in.PodSpec.DeepCopyInto(&out.PodSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaPoolSpec.
+func (in *ReplicaPoolSpec) DeepCopy() *ReplicaPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaPoolStatus) DeepCopyInto(out *ReplicaPoolStatus) {
+	*out = *in
+	out.StatefulAppStatus = in.StatefulAppStatus
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaPoolStatus.
+func (in *ReplicaPoolStatus) DeepCopy() *ReplicaPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSourceHost) DeepCopyInto(out *ReplicationSourceHost) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceHost.
+func (in *ReplicationSourceHost) DeepCopy() *ReplicationSourceHost {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSourceHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSourceSpec) DeepCopyInto(out *ReplicationSourceSpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]ReplicationSourceHost, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceSpec.
+func (in *ReplicationSourceSpec) DeepCopy() *ReplicationSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretsProviderSpec) DeepCopyInto(out *SecretsProviderSpec) {
+	*out = *in
+	out.RefreshInterval = in.RefreshInterval
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretsProviderSpec.
+func (in *SecretsProviderSpec) DeepCopy() *SecretsProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretsProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceExpose) DeepCopyInto(out *ServiceExpose) {
 	*out = *in
@@ -608,6 +1116,76 @@ func (in *StatefulAppStatus) DeepCopy() *StatefulAppStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserGrant) DeepCopyInto(out *UserGrant) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]Privilege, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGrant.
+func (in *UserGrant) DeepCopy() *UserGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(UserGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserResourceLimits) DeepCopyInto(out *UserResourceLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserResourceLimits.
+func (in *UserResourceLimits) DeepCopy() *UserResourceLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(UserResourceLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKubernetesAuthSpec) DeepCopyInto(out *VaultKubernetesAuthSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKubernetesAuthSpec.
+func (in *VaultKubernetesAuthSpec) DeepCopy() *VaultKubernetesAuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKubernetesAuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSpec) DeepCopyInto(out *VaultSpec) {
+	*out = *in
+	if in.KubernetesAuth != nil {
+		in, out := &in.KubernetesAuth, &out.KubernetesAuth
+		*out = new(VaultKubernetesAuthSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSpec.
+func (in *VaultSpec) DeepCopy() *VaultSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSpec) DeepCopyInto(out *VolumeSpec) {
 	*out = *in
@@ -0,0 +1,6 @@
+#!/bin/bash
+
+set -o errexit
+set -o xtrace
+
+install -o "$(id -u)" -g "$(id -g)" -m 0755 -D /orc-discovery /opt/percona/orc-discovery
@@ -1,19 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sjmudd/stopwatch"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/db"
 	"github.com/percona/percona-server-mysql-operator/pkg/k8s"
 	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
 	"github.com/percona/percona-server-mysql-operator/pkg/replicator"
@@ -27,12 +32,12 @@ func main() {
 	defer f.Close()
 	log.SetOutput(f)
 
-	if err := bootstrap(); err != nil {
+	if err := bootstrap(context.Background()); err != nil {
 		log.Fatalf("bootstrap failed: %v", err)
 	}
 }
 
-func bootstrap() error {
+func bootstrap(ctx context.Context) error {
 	timer := stopwatch.NewNamedStopwatch()
 	err := timer.AddMany([]string{"clone", "total"})
 	if err != nil {
@@ -53,11 +58,15 @@ func bootstrap() error {
 	}
 	log.Printf("Peers: %v", peers.List())
 
-	primary, replicas, err := getTopology(peers)
+	primary, replicas, err := getTopology(ctx, peers)
 	if err != nil {
 		return errors.Wrap(err, "select donor")
 	}
 	log.Printf("Primary: %s Replicas: %v", primary, replicas)
+	if primary == "" {
+		log.Println("Primary is not found, replication is configured by the operator")
+		return nil
+	}
 
 	fqdn, err := getFQDN(mysqlSvc)
 	if err != nil {
@@ -82,12 +91,18 @@ func bootstrap() error {
 	}
 	log.Printf("PrimaryIP: %s", primaryIp)
 
-	donor, err := selectDonor(fqdn, primary, replicas)
+	donor, err := selectDonor(ctx, fqdn, primary, replicas)
 	if err != nil {
 		return errors.Wrap(err, "select donor")
 	}
 	log.Printf("Donor: %s", donor)
 
+	if primary == fqdn || primaryIp == podIp {
+		if host := os.Getenv(mysql.MigrationHostEnv); host != "" {
+			return cloneMigrationSource(ctx, podIp, fqdn, host)
+		}
+	}
+
 	if donor == "" || donor == fqdn || primary == fqdn || primaryIp == podIp {
 		return nil
 	}
@@ -98,13 +113,24 @@ func bootstrap() error {
 		return errors.Wrapf(err, "get %s password", apiv1alpha1.UserOperator)
 	}
 
-	db, err := replicator.NewReplicator("operator", operatorPass, podIp, mysql.DefaultAdminPort)
+	db, err := connect(ctx, operatorPass, podIp, fqdn, cloneReadTimeout)
 	if err != nil {
 		return errors.Wrap(err, "connect to db")
 	}
 	defer db.Close()
 
-	needsClone, err := db.NeedsClone(donor, mysql.DefaultAdminPort)
+	// skip_slave_start is persisted on pods of replica pools in recovery,
+	// they must not be cloned or replicate from the primary
+	skipReplication, err := db.IsReplicaStartDisabled(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check skip_slave_start")
+	}
+	if skipReplication {
+		log.Println("Replication is disabled by skip_slave_start")
+		return nil
+	}
+
+	needsClone, err := db.NeedsClone(ctx, donor, mysql.DefaultAdminPort)
 	if err != nil {
 		return errors.Wrap(err, "check if a clone is needed")
 	}
@@ -112,7 +138,7 @@ func bootstrap() error {
 	log.Printf("Clone needed: %t", needsClone)
 	if needsClone {
 		log.Println("Checking if a clone in progress")
-		inProgress, err := db.CloneInProgress()
+		inProgress, err := db.CloneInProgress(ctx)
 		if err != nil {
 			return errors.Wrap(err, "check if a clone in progress")
 		}
@@ -124,7 +150,7 @@ func bootstrap() error {
 
 		timer.Start("clone")
 		log.Printf("Cloning from %s", donor)
-		err = db.Clone(donor, "operator", operatorPass, mysql.DefaultAdminPort)
+		err = db.Clone(ctx, donor, "operator", operatorPass, mysql.DefaultAdminPort)
 		timer.Stop("clone")
 		log.Printf("Clone finished in %f seconds", timer.ElapsedSeconds("clone"))
 		if err != nil {
@@ -132,7 +158,7 @@ func bootstrap() error {
 		}
 	}
 
-	rStatus, _, err := db.ReplicationStatus()
+	rStatus, _, err := db.ReplicationStatus(ctx)
 	if err != nil {
 		return errors.Wrap(err, "check replication status")
 	}
@@ -145,7 +171,7 @@ func bootstrap() error {
 			return errors.Wrapf(err, "get %s password", apiv1alpha1.UserReplication)
 		}
 
-		if err := db.StartReplication(primary, replicaPass, mysql.DefaultPort); err != nil {
+		if err := db.StartReplication(ctx, primary, replicaPass, mysql.DefaultPort); err != nil {
 			return errors.Wrap(err, "start replication")
 		}
 	}
@@ -153,6 +179,52 @@ func bootstrap() error {
 	return nil
 }
 
+// cloneMigrationSource clones the primary from the external server of spec.migration.
+// Instances which were cloned before, e.g. from another pod, are never cloned from it.
+func cloneMigrationSource(ctx context.Context, podIp, fqdn, host string) error {
+	port, err := strconv.ParseInt(os.Getenv(mysql.MigrationPortEnv), 10, 32)
+	if err != nil {
+		return errors.Wrapf(err, "parse %s", mysql.MigrationPortEnv)
+	}
+
+	operatorPass, err := getSecret(apiv1alpha1.UserOperator)
+	if err != nil {
+		return errors.Wrapf(err, "get %s password", apiv1alpha1.UserOperator)
+	}
+
+	db, err := connect(ctx, operatorPass, podIp, fqdn, cloneReadTimeout)
+	if err != nil {
+		return errors.Wrap(err, "connect to db")
+	}
+	defer db.Close()
+
+	cloned, err := db.IsCloned(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check if instance is cloned")
+	}
+	if cloned {
+		log.Println("Instance is already cloned, skipping clone from migration source")
+		return nil
+	}
+
+	inProgress, err := db.CloneInProgress(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check if a clone in progress")
+	}
+	if inProgress {
+		log.Println("Clone from migration source is in progress")
+		return nil
+	}
+
+	log.Printf("Cloning from migration source %s:%d", host, port)
+	err = db.Clone(ctx, host, os.Getenv(mysql.MigrationUserEnv), os.Getenv(mysql.MigrationPasswordEnv), int32(port))
+	if err != nil {
+		return errors.Wrapf(err, "clone from migration source %s", host)
+	}
+
+	return nil
+}
+
 func getFQDN(svcName string) (string, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -177,6 +249,30 @@ func getSecret(username apiv1alpha1.SystemUser) (string, error) {
 	return strings.TrimSpace(string(sBytes)), nil
 }
 
+// cloneReadTimeout disables the read timeout of connections which run clone,
+// it doesn't return until all data is copied
+const cloneReadTimeout = db.NoTimeout
+
+// connect opens a connection of the operator user to the admin port of the
+// host. The certificate is verified against serverName, it's required if host
+// is an IP. Zero readTimeout is the default one.
+func connect(ctx context.Context, operatorPass, host, serverName string, readTimeout time.Duration) (replicator.Replicator, error) {
+	ca, err := ioutil.ReadFile(mysql.TLSCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", mysql.TLSCAFile)
+	}
+
+	return replicator.NewReplicator(ctx, db.Config{
+		User:        apiv1alpha1.UserOperator,
+		Password:    operatorPass,
+		Host:        host,
+		Port:        mysql.DefaultAdminPort,
+		CA:          ca,
+		ServerName:  serverName,
+		ReadTimeout: readTimeout,
+	})
+}
+
 func getPodIP(hostname string) (string, error) {
 	addrs, err := net.LookupHost(hostname)
 	if err != nil {
@@ -204,28 +300,34 @@ func lookup(svcName string) (sets.String, error) {
 	return endpoints, nil
 }
 
-func getTopology(peers sets.String) (string, []string, error) {
+// getTopology returns the primary and the replicas among peers. The primary
+// is empty if it can't be chosen safely, e.g. if peers have diverged.
+func getTopology(ctx context.Context, peers sets.String) (string, []string, error) {
 	replicas := sets.NewString()
 	primary := ""
 
+	// connections and executed GTIDs of peers by report_host
+	dbs := make(map[string]replicator.Replicator)
+	gtids := make(map[string]string)
+
 	operatorPass, err := getSecret(apiv1alpha1.UserOperator)
 	if err != nil {
 		return "", nil, errors.Wrapf(err, "get %s password", apiv1alpha1.UserOperator)
 	}
 
 	for _, peer := range peers.List() {
-		db, err := replicator.NewReplicator("operator", operatorPass, peer, mysql.DefaultAdminPort)
+		db, err := connect(ctx, operatorPass, peer, "", 0)
 		if err != nil {
 			return "", nil, errors.Wrapf(err, "connect to %s", peer)
 		}
 		defer db.Close()
 
-		status, source, err := db.ReplicationStatus()
+		status, source, err := db.ReplicationStatus(ctx)
 		if err != nil {
 			return "", nil, errors.Wrap(err, "check replication status")
 		}
 
-		replicaHost, err := db.ReportHost()
+		replicaHost, err := db.ReportHost(ctx)
 		if err != nil {
 			return "", nil, errors.Wrap(err, "get report_host")
 		}
@@ -237,22 +339,73 @@ func getTopology(peers sets.String) (string, []string, error) {
 		if status == replicator.ReplicationStatusActive {
 			primary = source
 		}
+
+		gtid, err := db.GTIDExecuted(ctx)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "get executed GTIDs of %s", peer)
+		}
+		dbs[replicaHost] = db
+		gtids[replicaHost] = gtid
 	}
 
 	if primary == "" && peers.Len() == 1 {
 		primary = peers.List()[0]
+	} else if primary == "" && replicas.Len() == 0 {
+		log.Println("None of the peers has report_host")
 	} else if primary == "" {
-		primary = replicas.List()[0]
+		// Nobody is replicating, e.g. all pods were restarted at once.
+		// Choose the most advanced peer to not lose committed transactions.
+		primary, err = mostAdvanced(ctx, dbs, gtids)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "select most advanced peer")
+		}
+		if primary == "" {
+			// The operator elects the primary of diverged peers, it may
+			// require spec.mysql.forceUnsafeBootstrap to lose transactions.
+			log.Printf("Peers have diverged, none of them has all transactions: %v", gtids)
+		}
 	}
 
-	if replicas.Len() > 0 {
-		replicas.Delete(primary)
-	}
+	replicas.Delete(primary)
 
 	return primary, replicas.List(), nil
 }
 
-func selectDonor(fqdn, primary string, replicas []string) (string, error) {
+// mostAdvanced returns the host which executed GTIDs are a superset of GTIDs
+// executed on all other hosts. It returns empty string if there is no such host.
+func mostAdvanced(ctx context.Context, dbs map[string]replicator.Replicator, gtids map[string]string) (string, error) {
+	hosts := make([]string, 0, len(gtids))
+	for host := range gtids {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, candidate := range hosts {
+		superset := true
+		for _, host := range hosts {
+			if host == candidate {
+				continue
+			}
+
+			ok, err := dbs[candidate].IsGTIDSubset(ctx, gtids[host], gtids[candidate])
+			if err != nil {
+				return "", errors.Wrapf(err, "compare GTIDs of %s and %s", host, candidate)
+			}
+			if !ok {
+				superset = false
+				break
+			}
+		}
+
+		if superset {
+			return candidate, nil
+		}
+	}
+
+	return "", nil
+}
+
+func selectDonor(ctx context.Context, fqdn, primary string, replicas []string) (string, error) {
 	donor := ""
 
 	operatorPass, err := getSecret(apiv1alpha1.UserOperator)
@@ -261,7 +414,7 @@ func selectDonor(fqdn, primary string, replicas []string) (string, error) {
 	}
 
 	for _, replica := range replicas {
-		db, err := replicator.NewReplicator("operator", operatorPass, replica, mysql.DefaultAdminPort)
+		db, err := connect(ctx, operatorPass, replica, "", 0)
 		if err != nil {
 			continue
 		}
@@ -1,28 +1,35 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/db"
+	"github.com/percona/percona-server-mysql-operator/pkg/k8s"
 	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
 	"github.com/percona/percona-server-mysql-operator/pkg/replicator"
 )
 
 func main() {
+	ctx := context.Background()
+
 	switch os.Args[1] {
 	case "readiness":
-		if err := checkReadiness(); err != nil {
+		if err := checkReadiness(ctx); err != nil {
 			log.Fatalf("readiness check failed: %v", err)
 		}
 	case "liveness":
-		if err := checkLiveness(); err != nil {
+		if err := checkLiveness(ctx); err != nil {
 			log.Fatalf("liveness check failed: %v", err)
 		}
 	default:
@@ -30,7 +37,15 @@ func main() {
 	}
 }
 
-func checkReadiness() error {
+func checkReadiness(ctx context.Context) error {
+	fenced, err := isFenced()
+	if err != nil {
+		return errors.Wrap(err, "check if instance is fenced")
+	}
+	if fenced {
+		return errors.New("instance is fenced")
+	}
+
 	podIP, err := getPodIP()
 	if err != nil {
 		return errors.Wrap(err, "get pod IP")
@@ -41,19 +56,19 @@ func checkReadiness() error {
 		return errors.Wrapf(err, "get %s password", apiv1alpha1.UserOperator)
 	}
 
-	db, err := replicator.NewReplicator(apiv1alpha1.UserOperator, operatorPass, podIP, mysql.DefaultAdminPort)
+	db, err := connect(ctx, operatorPass, podIP)
 	if err != nil {
 		return errors.Wrap(err, "connect to db")
 	}
 	defer db.Close()
 
-	readOnly, err := db.IsReadonly()
+	readOnly, err := db.IsReadonly(ctx)
 	if err != nil {
 		return errors.Wrap(err, "check read only status")
 	}
 
 	// if isReplica is true, replication is active
-	isReplica, err := db.IsReplica()
+	isReplica, err := db.IsReplica(ctx)
 	if err != nil {
 		return errors.Wrap(err, "check replica status")
 	}
@@ -62,10 +77,44 @@ func checkReadiness() error {
 		return errors.New("replica is not read only")
 	}
 
+	if isReplica {
+		if err := checkReplicationLag(ctx, db); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func checkLiveness() error {
+// checkReplicationLag fails if replication lag measured by heartbeat
+// is higher than MAX_REPLICATION_LAG seconds
+func checkReplicationLag(ctx context.Context, db replicator.Replicator) error {
+	v, ok := os.LookupEnv(mysql.MaxReplicationLagEnv)
+	if !ok {
+		return nil
+	}
+
+	maxLag, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "parse %s", mysql.MaxReplicationLagEnv)
+	}
+	if maxLag <= 0 {
+		return nil
+	}
+
+	lag, err := db.ReplicationLag(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get replication lag")
+	}
+
+	if lag > maxLag {
+		return errors.Errorf("replication lag %ds is higher than %ds", lag, maxLag)
+	}
+
+	return nil
+}
+
+func checkLiveness(ctx context.Context) error {
 	podIP, err := getPodIP()
 	if err != nil {
 		return errors.Wrap(err, "get pod IP")
@@ -76,13 +125,37 @@ func checkLiveness() error {
 		return errors.Wrapf(err, "get %s password", apiv1alpha1.UserOperator)
 	}
 
-	db, err := replicator.NewReplicator("operator", operatorPass, podIP, mysql.DefaultAdminPort)
+	db, err := connect(ctx, operatorPass, podIP)
 	if err != nil {
 		return errors.Wrap(err, "connect to db")
 	}
 	defer db.Close()
 
-	return db.DumbQuery()
+	return db.DumbQuery(ctx)
+}
+
+// isFenced checks if the pod is labeled as fenced by the operator.
+// Labels are mounted using Downward API in format key="value", one per line.
+func isFenced() (bool, error) {
+	path := filepath.Join(mysql.PodInfoMountPath, mysql.PodLabelsFileName)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "read %s", path)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || kv[0] != apiv1alpha1.MySQLFencedLabel {
+			continue
+		}
+
+		return strings.Trim(kv[1], `"`) == "true", nil
+	}
+
+	return false, nil
 }
 
 func getSecret(username string) (string, error) {
@@ -95,6 +168,44 @@ func getSecret(username string) (string, error) {
 	return strings.TrimSpace(string(sBytes)), nil
 }
 
+// connect opens a connection of the operator user to the admin port of the
+// instance by its IP. The certificate is verified against the host name of the pod.
+func connect(ctx context.Context, operatorPass, podIP string) (replicator.Replicator, error) {
+	ca, err := ioutil.ReadFile(mysql.TLSCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", mysql.TLSCAFile)
+	}
+
+	fqdn, err := getFQDN()
+	if err != nil {
+		return nil, errors.Wrap(err, "get FQDN")
+	}
+
+	return replicator.NewReplicator(ctx, db.Config{
+		User:       apiv1alpha1.UserOperator,
+		Password:   operatorPass,
+		Host:       podIP,
+		Port:       mysql.DefaultAdminPort,
+		CA:         ca,
+		ServerName: fqdn,
+	})
+}
+
+// getFQDN returns host name of the pod in its governing service
+func getFQDN() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", errors.Wrap(err, "get hostname")
+	}
+
+	namespace, err := k8s.DefaultAPINamespace()
+	if err != nil {
+		return "", errors.Wrap(err, "get namespace")
+	}
+
+	return fmt.Sprintf("%s.%s.%s", hostname, os.Getenv("SERVICE_NAME"), namespace), nil
+}
+
 func getPodIP() (string, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -28,7 +28,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -57,8 +57,12 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var failoverHookAddr string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&failoverHookAddr, "failover-hook-bind-address", ":8082",
+		"The address the failover hook endpoint binds to. Orchestrator calls it after failover. "+
+			"Set to empty string to disable the hook.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -76,50 +80,92 @@ func main() {
 		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	options := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "08db0feb.percona.com",
-		Namespace:              ns,
-	})
+	}
+
+	namespaces := k8s.WatchNamespaces(ns)
+	switch len(namespaces) {
+	case 0:
+		// cluster-wide mode, watch all namespaces
+	case 1:
+		options.Namespace = namespaces[0]
+	default:
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	nsClient := client.NewNamespacedClient(mgr.GetClient(), ns)
-
 	serverVersion, err := platform.GetServerVersion()
 	if err != nil {
 		setupLog.Error(err, "unable to get server version")
 		os.Exit(1)
 	}
 
+	var failoverHook *controllers.FailoverHook
+	if failoverHookAddr != "" {
+		failoverHook = controllers.NewFailoverHook(
+			mgr.GetClient(),
+			mgr.GetEventRecorderFor("ps-failover-hook"),
+			failoverHookAddr,
+		)
+		if err := mgr.Add(failoverHook); err != nil {
+			setupLog.Error(err, "unable to set up failover hook")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controllers.PerconaServerMySQLReconciler{
-		Client:        nsClient,
+		Client:        mgr.GetClient(),
+		APIReader:     mgr.GetAPIReader(),
 		Scheme:        mgr.GetScheme(),
 		ServerVersion: serverVersion,
+		Recorder:      mgr.GetEventRecorderFor("ps-controller"),
+		FailoverHook:  failoverHook,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMySQL")
 		os.Exit(1)
 	}
 	if err = (&controllers.PerconaServerMySQLBackupReconciler{
-		Client: nsClient,
+		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMySQLBackup")
 		os.Exit(1)
 	}
 	if err = (&controllers.PerconaServerMySQLRestoreReconciler{
-		Client: nsClient,
+		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMySQLRestore")
 		os.Exit(1)
 	}
+	if err = (&controllers.PerconaServerMySQLUserReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		ServerVersion: serverVersion,
+		Recorder:      mgr.GetEventRecorderFor("ps-user-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMySQLUser")
+		os.Exit(1)
+	}
+	if err = (&controllers.PerconaServerMySQLDatabaseReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		ServerVersion: serverVersion,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PerconaServerMySQLDatabase")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -133,6 +179,7 @@ func main() {
 
 	setupLog.Info(
 		"starting manager",
+		"WatchNamespaces", namespaces,
 		"GitCommit", GitCommit,
 		"BuildTime", BuildTime,
 		"Platform", serverVersion.Platform,
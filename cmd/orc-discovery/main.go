@@ -0,0 +1,294 @@
+// orc-discovery registers MySQL instances in Orchestrator. It polls SRV records
+// of the MySQL service and calls Orchestrator discover API for the new instances
+// and forget API for the ones which are missing for -forget-after, so pods
+// which are restarted or evicted are not forgotten. Instances registered before
+// the agent started are loaded from Orchestrator, so removed ones are forgotten
+// after restart of the agent too.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8sretry "k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
+	"github.com/percona/percona-server-mysql-operator/pkg/orchestrator"
+)
+
+const (
+	pollPeriod = time.Second
+	// defaultForgetAfter is longer than a restart of MySQL pod usually takes,
+	// scaled down instances are forgotten by the operator right away
+	defaultForgetAfter = 10 * time.Minute
+)
+
+var backoff = wait.Backoff{
+	Steps:    5,
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+type agent struct {
+	log logr.Logger
+
+	// lookupService is the service to get SRV records of MySQL pods from
+	lookupService string
+	// hostService is the governing service of MySQL pods, instances are
+	// registered as <pod>.<hostService>.<namespace> which is their report_host
+	hostService string
+	namespace   string
+	port        int32
+	api         *orchestrator.API
+
+	// discovered are the hosts successfully registered in Orchestrator
+	discovered sets.String
+	// loaded is true if the hosts registered before start were loaded from Orchestrator
+	loaded bool
+	// missing are the discovered hosts without SRV records and the time they were
+	// first found missing. Hosts are forgotten if they are missing for forgetAfter.
+	missing     map[string]time.Time
+	forgetAfter time.Duration
+}
+
+func main() {
+	a := &agent{discovered: sets.NewString(), missing: make(map[string]time.Time)}
+
+	var (
+		port                               int
+		apiHost, apiUser, passFile, caFile string
+	)
+	flag.StringVar(&a.lookupService, "service", "", "Service to lookup SRV records of MySQL pods.")
+	flag.StringVar(&a.hostService, "host-service", "", "Governing service of MySQL pods used in hostnames of instances. Defaults to -service.")
+	flag.StringVar(&a.namespace, "ns", "", "The namespace of MySQL pods. If unspecified, the POD_NAMESPACE env var is used.")
+	flag.StringVar(&apiHost, "orchestrator", "http://127.0.0.1:3000", "Orchestrator API address.")
+	flag.StringVar(&apiUser, "api-user", "", "Orchestrator API user.")
+	flag.StringVar(&passFile, "api-password-file", "", "File with Orchestrator API password.")
+	flag.StringVar(&caFile, "ca-file", "", "CA certificate to verify Orchestrator API certificate.")
+	flag.IntVar(&port, "port", mysql.DefaultPort, "MySQL port.")
+	flag.DurationVar(&a.forgetAfter, "forget-after", defaultForgetAfter, "Time a MySQL host is missing before it is forgotten.")
+	opts := zap.Options{}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	a.log = ctrl.Log.WithName("orc-discovery")
+	a.port = int32(port)
+
+	if a.namespace == "" {
+		a.namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if a.hostService == "" {
+		a.hostService = a.lookupService
+	}
+	if a.lookupService == "" || a.namespace == "" {
+		a.log.Error(errors.New("incomplete args"), "-service and -ns or POD_NAMESPACE env var are required")
+		os.Exit(1)
+	}
+
+	api, err := newAPI(apiHost, apiUser, passFile, caFile)
+	if err != nil {
+		a.log.Error(err, "failed to create Orchestrator API client")
+		os.Exit(1)
+	}
+	a.api = api
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1)
+	defer cancel()
+
+	a.log.Info("Starting discovery", "service", a.lookupService, "namespace", a.namespace, "orchestrator", apiHost)
+
+	wait.UntilWithContext(ctx, a.sync, pollPeriod)
+
+	a.log.Info("Discovery stopped")
+}
+
+func newAPI(host, user, passFile, caFile string) (*orchestrator.API, error) {
+	var pass, caCert []byte
+	var err error
+
+	if passFile != "" {
+		pass, err = ioutil.ReadFile(passFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read password file")
+		}
+	}
+
+	if caFile != "" {
+		caCert, err = ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read CA file")
+		}
+	}
+
+	return orchestrator.NewAPI(host, user, strings.TrimSpace(string(pass)), caCert)
+}
+
+// sync discovers new and forgets removed MySQL instances.
+// Failed hosts are retried on the next sync.
+func (a *agent) sync(ctx context.Context) {
+	if !a.loaded {
+		if err := a.load(ctx); err != nil {
+			a.log.Error(err, "failed to load instances from Orchestrator")
+			return
+		}
+	}
+
+	hosts, err := a.lookup()
+	if err != nil {
+		a.log.Error(err, "failed to lookup MySQL hosts")
+		return
+	}
+
+	added, removed := diff(hosts, a.discovered)
+	if hosts.Len() > 0 {
+		removed = a.expired(removed, time.Now())
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	a.log.Info("MySQL hosts changed", "added", added, "removed", removed)
+
+	for _, host := range added {
+		err := a.retry(ctx, func() error {
+			return orchestrator.Discover(ctx, a.api, host, a.port)
+		})
+		if err != nil {
+			a.log.Error(err, "failed to discover instance", "host", host)
+			continue
+		}
+
+		a.discovered.Insert(host)
+		a.log.Info("Discovered instance", "host", host)
+	}
+
+	for _, host := range removed {
+		err := a.retry(ctx, func() error {
+			return orchestrator.Forget(ctx, a.api, host, a.port)
+		})
+		if err != nil {
+			a.log.Error(err, "failed to forget instance", "host", host)
+			continue
+		}
+
+		a.discovered.Delete(host)
+		delete(a.missing, host)
+		a.log.Info("Forgot instance", "host", host)
+	}
+}
+
+// load adds instances of the MySQL service which are already registered in
+// Orchestrator to the discovered hosts
+func (a *agent) load(ctx context.Context) error {
+	instances, err := orchestrator.AllInstances(ctx, a.api)
+	if err != nil {
+		return errors.Wrap(err, "get instances")
+	}
+
+	registered := registeredHosts(instances, a.hostService, a.namespace, a.port)
+	a.discovered = a.discovered.Union(registered)
+	a.loaded = true
+
+	a.log.Info("Loaded instances from Orchestrator", "hosts", registered.List())
+
+	return nil
+}
+
+// registeredHosts returns hostnames of the instances in
+// <pod>.<hostService>.<namespace> format which listen on the port.
+// Instances of other services, e.g. of replica pools, are skipped.
+func registeredHosts(instances []*orchestrator.Instance, hostService, namespace string, port int32) sets.String {
+	hosts := sets.NewString()
+	suffix := fmt.Sprintf(".%s.%s", hostService, namespace)
+	for _, inst := range instances {
+		host := inst.Key.Hostname
+		if inst.Key.Port != port || !strings.HasSuffix(host, suffix) {
+			continue
+		}
+		if pod := strings.TrimSuffix(host, suffix); pod == "" || strings.Contains(pod, ".") {
+			continue
+		}
+		hosts.Insert(host)
+	}
+	return hosts
+}
+
+// diff returns sorted hosts which should be discovered and forgotten
+func diff(hosts, discovered sets.String) (added, removed []string) {
+	added = hosts.Difference(discovered).List()
+	removed = discovered.Difference(hosts).List()
+	if hosts.Len() == 0 {
+		// DNS may return no records for a short time, instances are
+		// not forgotten until at least one MySQL pod is found
+		removed = nil
+	}
+	return added, removed
+}
+
+// expired returns the missing hosts which should be forgotten because they
+// are missing for forgetAfter. Hosts which are found again are not tracked.
+func (a *agent) expired(missing []string, now time.Time) []string {
+	current := sets.NewString(missing...)
+	for host := range a.missing {
+		if !current.Has(host) {
+			delete(a.missing, host)
+			a.log.Info("Missing instance is found again", "host", host)
+		}
+	}
+
+	expired := make([]string, 0)
+	for _, host := range missing {
+		since, ok := a.missing[host]
+		if !ok {
+			since = now
+			a.missing[host] = since
+			a.log.Info("Instance is missing", "host", host, "forgetAfter", a.forgetAfter)
+		}
+		if now.Sub(since) >= a.forgetAfter {
+			expired = append(expired, host)
+		}
+	}
+
+	return expired
+}
+
+func (a *agent) retry(ctx context.Context, f func() error) error {
+	return k8sretry.OnError(backoff, func(error) bool { return ctx.Err() == nil }, f)
+}
+
+// lookup returns hostnames of MySQL instances in <pod>.<hostService>.<namespace> format
+func (a *agent) lookup() (sets.String, error) {
+	hosts := sets.NewString()
+
+	_, records, err := net.LookupSRV("", "", a.lookupService)
+	if err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			// service has no endpoints
+			return hosts, nil
+		}
+		return nil, errors.Wrapf(err, "lookup SRV records of %s", a.lookupService)
+	}
+
+	for _, r := range records {
+		pod := strings.Split(r.Target, ".")[0]
+		hosts.Insert(fmt.Sprintf("%s.%s.%s", pod, a.hostService, a.namespace))
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/percona/percona-server-mysql-operator/pkg/orchestrator"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		hosts      []string
+		discovered []string
+		added      []string
+		removed    []string
+	}{
+		{
+			name:  "first sync",
+			hosts: []string{"cluster1-mysql-1.cluster1-mysql.ns", "cluster1-mysql-0.cluster1-mysql.ns"},
+			added: []string{"cluster1-mysql-0.cluster1-mysql.ns", "cluster1-mysql-1.cluster1-mysql.ns"},
+		},
+		{
+			name:       "no changes",
+			hosts:      []string{"cluster1-mysql-0.cluster1-mysql.ns"},
+			discovered: []string{"cluster1-mysql-0.cluster1-mysql.ns"},
+		},
+		{
+			name:       "scale up and down",
+			hosts:      []string{"cluster1-mysql-0.cluster1-mysql.ns", "cluster1-mysql-2.cluster1-mysql.ns"},
+			discovered: []string{"cluster1-mysql-0.cluster1-mysql.ns", "cluster1-mysql-1.cluster1-mysql.ns"},
+			added:      []string{"cluster1-mysql-2.cluster1-mysql.ns"},
+			removed:    []string{"cluster1-mysql-1.cluster1-mysql.ns"},
+		},
+		{
+			name:       "instances aren't forgotten without records",
+			discovered: []string{"cluster1-mysql-0.cluster1-mysql.ns"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diff(sets.NewString(tt.hosts...), sets.NewString(tt.discovered...))
+			if len(added) != len(tt.added) || len(added) > 0 && !reflect.DeepEqual(added, tt.added) {
+				t.Errorf("expected added %v, got %v", tt.added, added)
+			}
+			if len(removed) != len(tt.removed) || len(removed) > 0 && !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("expected removed %v, got %v", tt.removed, removed)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	a := &agent{log: logr.Discard(), missing: make(map[string]time.Time), forgetAfter: time.Minute}
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	pod0 := "cluster1-mysql-0.cluster1-mysql.ns"
+	pod1 := "cluster1-mysql-1.cluster1-mysql.ns"
+
+	steps := []struct {
+		name     string
+		after    time.Duration
+		missing  []string
+		expected []string
+	}{
+		{"pod is restarted", 0, []string{pod0}, []string{}},
+		{"pod is still restarting", 30 * time.Second, []string{pod0}, []string{}},
+		{"pod is back", 40 * time.Second, nil, []string{}},
+		{"pod is restarted again", 50 * time.Second, []string{pod0}, []string{}},
+		{"another pod is removed", 100 * time.Second, []string{pod0, pod1}, []string{}},
+		{"restarted pod is missing for the grace period", 110 * time.Second, []string{pod0, pod1}, []string{pod0}},
+		{"removed pod is missing for the grace period", 160 * time.Second, []string{pod1}, []string{pod1}},
+	}
+
+	for _, step := range steps {
+		got := a.expired(step.missing, start.Add(step.after))
+		if !reflect.DeepEqual(got, step.expected) {
+			t.Errorf("%s: expected %v, got %v", step.name, step.expected, got)
+		}
+	}
+}
+
+func TestExpiredWithoutGracePeriod(t *testing.T) {
+	a := &agent{log: logr.Discard(), missing: make(map[string]time.Time)}
+
+	missing := []string{"cluster1-mysql-1.cluster1-mysql.ns"}
+	if got := a.expired(missing, time.Now()); !reflect.DeepEqual(got, missing) {
+		t.Errorf("expected %v to be forgotten right away, got %v", missing, got)
+	}
+}
+
+func TestRegisteredHosts(t *testing.T) {
+	instances := []*orchestrator.Instance{
+		{Key: orchestrator.InstanceKey{Hostname: "cluster1-mysql-0.cluster1-mysql.ns", Port: 3306}},
+		{Key: orchestrator.InstanceKey{Hostname: "cluster1-mysql-1.cluster1-mysql.ns", Port: 3306}},
+		{Key: orchestrator.InstanceKey{Hostname: "cluster1-mysql-2.cluster1-mysql.ns", Port: 33062}},
+		{Key: orchestrator.InstanceKey{Hostname: "cluster1-mysql-delayed-0.cluster1-mysql-delayed.ns", Port: 3306}},
+		{Key: orchestrator.InstanceKey{Hostname: "cluster1-mysql-0.cluster1-mysql.other", Port: 3306}},
+		{Key: orchestrator.InstanceKey{Hostname: "cluster1-mysql-0.cluster1-mysql.ns.svc.cluster.local", Port: 3306}},
+		{Key: orchestrator.InstanceKey{Hostname: "10.0.0.1", Port: 3306}},
+	}
+
+	got := registeredHosts(instances, "cluster1-mysql", "ns", 3306).List()
+	expected := []string{"cluster1-mysql-0.cluster1-mysql.ns", "cluster1-mysql-1.cluster1-mysql.ns"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/all-instances" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"Key": {"Hostname": "cluster1-mysql-0.cluster1-mysql.ns", "Port": 3306}},
+			{"Key": {"Hostname": "cluster1-mysql-3.cluster1-mysql.ns", "Port": 3306}},
+			{"Key": {"Hostname": "cluster1-mysql-delayed-0.cluster1-mysql-delayed.ns", "Port": 3306}}
+		]`))
+	}))
+	defer srv.Close()
+
+	api, err := orchestrator.NewAPI(srv.URL, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &agent{
+		log:         logr.Discard(),
+		hostService: "cluster1-mysql",
+		namespace:   "ns",
+		port:        3306,
+		api:         api,
+		discovered:  sets.NewString("cluster1-mysql-1.cluster1-mysql.ns"),
+	}
+	if err := a.load(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !a.loaded {
+		t.Error("expected agent to be loaded")
+	}
+
+	expected := []string{
+		"cluster1-mysql-0.cluster1-mysql.ns",
+		"cluster1-mysql-1.cluster1-mysql.ns",
+		"cluster1-mysql-3.cluster1-mysql.ns",
+	}
+	if got := a.discovered.List(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected discovered %v, got %v", expected, got)
+	}
+
+	// instance of the deleted pod is forgotten after restart of the agent
+	hosts := sets.NewString("cluster1-mysql-0.cluster1-mysql.ns", "cluster1-mysql-1.cluster1-mysql.ns")
+	if _, removed := diff(hosts, a.discovered); !reflect.DeepEqual(removed, []string{"cluster1-mysql-3.cluster1-mysql.ns"}) {
+		t.Errorf("expected cluster1-mysql-3 to be forgotten, got %v", removed)
+	}
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/orchestrator"
+)
+
+const (
+	// FailoverHookServiceName is the name of the operator Service which exposes the failover hook
+	FailoverHookServiceName = "percona-server-mysql-operator"
+	FailoverHookServicePort = 8082
+)
+
+// FailoverNotice is sent by Orchestrator after failover or graceful takeover
+type FailoverNotice struct {
+	FailureType   string    `json:"failureType"`
+	FailedHost    string    `json:"failedHost"`
+	SuccessorHost string    `json:"successorHost"`
+	IsSuccessful  bool      `json:"isSuccessful"`
+	ReceivedAt    time.Time `json:"receivedAt"`
+}
+
+// FailoverHook is an HTTP endpoint which is called by Orchestrator
+// PostFailoverProcesses and PostGracefulTakeoverProcesses hooks.
+// It triggers reconciliation of the cluster right after the primary is changed.
+//
+// The hook is served by all replicas of the operator since the Service sends
+// requests to any of them. The notice is stored in an annotation of the
+// cluster, so the leader reconciles the cluster and gets the notice from it.
+// Requests are authenticated with the token of the cluster which is stored in
+// the orchestrator configuration Secret.
+type FailoverHook struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	Addr     string
+}
+
+func NewFailoverHook(cl client.Client, recorder record.EventRecorder, addr string) *FailoverHook {
+	return &FailoverHook{
+		Client:   cl,
+		Recorder: recorder,
+		Addr:     addr,
+	}
+}
+
+// NeedLeaderElection returns false, the hook is served by all replicas
+func (h *FailoverHook) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs HTTP server until context is cancelled
+func (h *FailoverHook) Start(ctx context.Context) error {
+	l := logf.FromContext(ctx).WithName("failoverHook")
+
+	mux := http.NewServeMux()
+	mux.Handle("/failover", h)
+
+	srv := &http.Server{
+		Addr:    h.Addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			l.Error(err, "failed to shutdown failover hook server")
+		}
+	}()
+
+	l.Info("Starting failover hook server", "addr", h.Addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "listen and serve")
+	}
+
+	return nil
+}
+
+// LastFailoverNotice returns the last failover notice received for the cluster
+func LastFailoverNotice(cr *apiv1alpha1.PerconaServerMySQL) (FailoverNotice, bool) {
+	notice := FailoverNotice{}
+
+	data, ok := cr.Annotations[string(apiv1alpha1.AnnotationFailoverNotice)]
+	if !ok {
+		return notice, false
+	}
+	if err := json.Unmarshal([]byte(data), &notice); err != nil {
+		return notice, false
+	}
+
+	return notice, true
+}
+
+func (h *FailoverHook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	l := logf.Log.WithName("failoverHook")
+
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := req.URL.Query()
+	nn := types.NamespacedName{Namespace: q.Get("namespace"), Name: q.Get("name")}
+	if nn.Namespace == "" || nn.Name == "" {
+		http.Error(w, "namespace and name are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := req.Context()
+
+	cr := &apiv1alpha1.PerconaServerMySQL{}
+	if err := h.Client.Get(ctx, nn, cr); err != nil {
+		if k8serrors.IsNotFound(err) {
+			http.Error(w, "cluster not found", http.StatusNotFound)
+			return
+		}
+		l.Error(err, "failed to get cluster", "cluster", nn.String())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.authenticate(ctx, cr, req); err != nil {
+		l.Info("Unauthorized failover notice", "cluster", nn.String(), "reason", err.Error())
+		w.Header().Set("WWW-Authenticate", `Basic realm="failover"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	notice := FailoverNotice{
+		FailureType:   q.Get("failureType"),
+		FailedHost:    q.Get("failedHost"),
+		SuccessorHost: q.Get("successorHost"),
+		IsSuccessful:  q.Get("isSuccessful") == "true",
+		ReceivedAt:    time.Now(),
+	}
+
+	l.Info("Received failover notice", "cluster", nn.String(), "notice", notice)
+
+	data, err := json.Marshal(notice)
+	if err != nil {
+		l.Error(err, "failed to marshal failover notice", "cluster", nn.String())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// update of the cluster triggers reconciliation by the leader
+	patch := client.MergeFrom(cr.DeepCopy())
+	if cr.Annotations == nil {
+		cr.Annotations = make(map[string]string)
+	}
+	cr.Annotations[string(apiv1alpha1.AnnotationFailoverNotice)] = string(data)
+	if err := h.Client.Patch(ctx, cr, patch); err != nil {
+		l.Error(err, "failed to store failover notice", "cluster", nn.String())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if notice.IsSuccessful {
+		h.Recorder.Eventf(cr, corev1.EventTypeNormal, "PrimaryChanged",
+			"Orchestrator %s: primary changed from %s to %s", notice.FailureType, notice.FailedHost, notice.SuccessorHost)
+	} else {
+		h.Recorder.Eventf(cr, corev1.EventTypeWarning, "FailoverFailed",
+			"Orchestrator %s of %s failed", notice.FailureType, notice.FailedHost)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *FailoverHook) authenticate(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL, req *http.Request) error {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return errors.New("no token")
+	}
+
+	s := &corev1.Secret{}
+	nn := types.NamespacedName{Name: orchestrator.ConfigSecretName(cr), Namespace: cr.Namespace}
+	if err := h.Client.Get(ctx, nn, s); err != nil {
+		return errors.Wrapf(err, "get Secret/%s", nn.Name)
+	}
+
+	expected := s.Data[orchestrator.FailoverHookTokenKey]
+	if len(expected) == 0 {
+		return errors.Errorf("Secret/%s has no failover hook token", nn.Name)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+		return errors.New("wrong token")
+	}
+
+	return nil
+}
+
+// hostAlias returns pod name from the MySQL hostname, e.g. cluster1-mysql-0 for cluster1-mysql-0.cluster1-mysql.ns
+func hostAlias(host string) string {
+	return strings.Split(host, ".")[0]
+}
@@ -23,7 +23,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -31,20 +34,27 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	k8sretry "k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/db"
 	"github.com/percona/percona-server-mysql-operator/pkg/k8s"
+	"github.com/percona/percona-server-mysql-operator/pkg/metrics"
 	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
 	"github.com/percona/percona-server-mysql-operator/pkg/orchestrator"
+	"github.com/percona/percona-server-mysql-operator/pkg/parallel"
 	"github.com/percona/percona-server-mysql-operator/pkg/platform"
 	"github.com/percona/percona-server-mysql-operator/pkg/replicator"
+	"github.com/percona/percona-server-mysql-operator/pkg/schedule"
 	"github.com/percona/percona-server-mysql-operator/pkg/secret"
 	"github.com/percona/percona-server-mysql-operator/pkg/users"
 	"github.com/percona/percona-server-mysql-operator/pkg/util"
@@ -53,19 +63,34 @@ import (
 // PerconaServerMySQLReconciler reconciles a PerconaServerMySQL object
 type PerconaServerMySQLReconciler struct {
 	client.Client
+	// APIReader reads objects directly from the API server. It's used to get
+	// objects from namespaces which are not watched by the operator.
+	APIReader     client.Reader
 	Scheme        *runtime.Scheme
 	ServerVersion *platform.ServerVersion
+	Recorder      record.EventRecorder
+	FailoverHook  *FailoverHook
+
+	// secretsSynced is the time passwords were copied from spec.secretsProvider by cluster
+	secretsSynced sync.Map
+	// vaultLogins are tokens of Vault Kubernetes auth by cluster, *secret.VaultLogin
+	vaultLogins sync.Map
+	// clones are the instances being cloned in background by namespaced name of the pod
+	clones sync.Map
 }
 
 //+kubebuilder:rbac:groups=ps.percona.com,resources=perconaservermysqls;perconaservermysqls/status;perconaservermysqls/finalizers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=pods;configmaps;services;secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PerconaServerMySQLReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&apiv1alpha1.PerconaServerMySQL{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.PerconaServerMySQL{})
+
+	return b.Complete(r)
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -88,6 +113,7 @@ func (r *PerconaServerMySQLReconciler) Reconcile(
 	cr, err := r.getCRWithDefaults(ctx, req.NamespacedName)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
+			metrics.DeleteCluster(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 
@@ -111,29 +137,32 @@ func (r *PerconaServerMySQLReconciler) doReconcile(
 	ctx context.Context,
 	cr *apiv1alpha1.PerconaServerMySQL,
 ) error {
-	if err := r.ensureUserSecrets(ctx, cr); err != nil {
-		return errors.Wrap(err, "users secret")
-	}
-	if err := r.reconcileUsers(ctx, cr); err != nil {
-		return errors.Wrap(err, "users")
-	}
-	if err := r.ensureTLSSecret(ctx, cr); err != nil {
-		return errors.Wrap(err, "TLS secret")
-	}
-	if err := r.reconcileServices(ctx, cr); err != nil {
-		return errors.Wrap(err, "services")
-	}
-	if err := r.reconcileDatabase(ctx, cr); err != nil {
-		return errors.Wrap(err, "database")
-	}
-	if err := r.reconcileOrchestrator(ctx, cr); err != nil {
-		return errors.Wrap(err, "orchestrator")
-	}
-	if err := r.reconcileReplication(ctx, cr); err != nil {
-		return errors.Wrap(err, "replication")
-	}
-	if err := r.cleanupOutdated(ctx, cr); err != nil {
-		return errors.Wrap(err, "cleanup outdated")
+	phases := []struct {
+		name      string
+		reconcile func(context.Context, *apiv1alpha1.PerconaServerMySQL) error
+	}{
+		{"users secret", r.ensureUserSecrets},
+		{"password rotation", r.reconcilePasswordRotation},
+		{"users", r.reconcileUsers},
+		{"system user grants", r.reconcileSystemUserGrants},
+		{"TLS secret", r.ensureTLSSecret},
+		{"binding secret", r.reconcileBindingSecret},
+		{"services", r.reconcileServices},
+		{"database", r.reconcileDatabase},
+		{"orchestrator", r.reconcileOrchestrator},
+		{"full cluster crash", r.reconcileFullClusterCrash},
+		{"replication", r.reconcileReplication},
+		{"split brain", r.reconcileSplitBrain},
+		{"cleanup outdated", r.cleanupOutdated},
+	}
+
+	for _, phase := range phases {
+		start := time.Now()
+		err := phase.reconcile(ctx, cr)
+		metrics.ObserveReconcilePhase(cr, phase.name, time.Since(start))
+		if err != nil {
+			return errors.Wrap(err, phase.name)
+		}
 	}
 
 	return nil
@@ -154,32 +183,176 @@ func (r *PerconaServerMySQLReconciler) getCRWithDefaults(
 	return cr, nil
 }
 
+// ensureUserSecrets makes sure spec.secretsName has passwords of system users.
+// If spec.secretsProvider is set, passwords are copied from the provider every
+// refresh interval.
 func (r *PerconaServerMySQLReconciler) ensureUserSecrets(
 	ctx context.Context,
 	cr *apiv1alpha1.PerconaServerMySQL,
 ) error {
-	nn := types.NamespacedName{
-		Namespace: cr.Namespace,
-		Name:      cr.Spec.SecretsName,
+	k8sSource := secret.NewKubernetesSource(r.Client, r.Scheme, cr)
+
+	var src secret.Source = k8sSource
+	if sp := cr.Spec.SecretsProvider; sp != nil {
+		synced, ok := r.secretsSynced.Load(types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace})
+		if ok && time.Since(synced.(time.Time)) < sp.RefreshInterval.Duration {
+			return nil
+		}
+
+		var err error
+		src, err = r.secretsProvider(ctx, cr)
+		if err != nil {
+			return errors.Wrap(err, "init secrets provider")
+		}
 	}
 
-	exists, err := k8s.ObjectExists(ctx, r.Client, nn, &corev1.Secret{})
+	passwords, err := src.Passwords(ctx)
 	if err != nil {
-		return errors.Wrap(err, "check if secret exists")
-	} else if exists {
+		return errors.Wrap(err, "get passwords")
+	}
+
+	if src != secret.Source(k8sSource) {
+		// Users which are not in the provider keep their passwords from the
+		// Secret, e.g. orchestrator-api added after the passwords were stored
+		// in the provider or all users if the provider is set for an existing
+		// cluster. Otherwise their passwords would be changed.
+		existing, err := k8sSource.Passwords(ctx)
+		if err != nil {
+			return errors.Wrap(err, "get passwords from Secret")
+		}
+
+		added := false
+		for user, pass := range existing {
+			if _, ok := passwords[user]; ok {
+				continue
+			}
+			if passwords == nil {
+				passwords = make(map[string][]byte, len(existing))
+			}
+			passwords[user] = pass
+			added = true
+		}
+		if added {
+			if err := src.SetPasswords(ctx, passwords); err != nil {
+				return errors.Wrap(err, "add passwords to secrets provider")
+			}
+		}
+	}
+
+	if passwords == nil {
+		passwords, err = secret.GeneratePasswords()
+		if err != nil {
+			return errors.Wrap(err, "generate passwords")
+		}
+
+		if err := src.SetPasswords(ctx, passwords); err != nil {
+			return errors.Wrap(err, "set passwords")
+		}
+	}
+
+	if err := r.ensureOrchestratorAPIPassword(ctx, cr, src, passwords); err != nil {
+		return errors.Wrap(err, "ensure Orchestrator API password")
+	}
+
+	if src == secret.Source(k8sSource) {
+		return nil
+	}
+
+	// reconcileUsers applies passwords changed in the provider
+	if err := k8sSource.SetPasswords(ctx, passwords); err != nil {
+		return errors.Wrapf(err, "copy passwords to Secret/%s", cr.Spec.SecretsName)
+	}
+	r.secretsSynced.Store(types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, time.Now())
+
+	return nil
+}
+
+// secretsProvider returns the store of spec.secretsProvider
+func (r *PerconaServerMySQLReconciler) secretsProvider(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) (secret.Source, error) {
+	vault := cr.Spec.SecretsProvider.Vault
+
+	var token string
+	if vault.TokenSecret != "" {
+		s := &corev1.Secret{}
+		nn := types.NamespacedName{Name: vault.TokenSecret, Namespace: cr.Namespace}
+		if err := r.Client.Get(ctx, nn, s); err != nil {
+			return nil, errors.Wrapf(err, "get Secret/%s", nn.Name)
+		}
+		token = string(s.Data["token"])
+		if token == "" {
+			return nil, errors.Errorf("Secret/%s has no token", nn.Name)
+		}
+	}
+
+	var ca []byte
+	if vault.CASecret != "" {
+		s := &corev1.Secret{}
+		nn := types.NamespacedName{Name: vault.CASecret, Namespace: cr.Namespace}
+		if err := r.Client.Get(ctx, nn, s); err != nil {
+			return nil, errors.Wrapf(err, "get Secret/%s", nn.Name)
+		}
+		ca = s.Data["ca.crt"]
+	}
+
+	login, _ := r.vaultLogins.LoadOrStore(types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, new(secret.VaultLogin))
+
+	return secret.NewVaultSource(ctx, vault, token, ca, login.(*secret.VaultLogin))
+}
+
+// storePasswords writes passwords to spec.secretsProvider, if it's set, and
+// to spec.secretsName
+func (r *PerconaServerMySQLReconciler) storePasswords(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	passwords map[string][]byte,
+) error {
+	if cr.Spec.SecretsProvider != nil {
+		src, err := r.secretsProvider(ctx, cr)
+		if err != nil {
+			return errors.Wrap(err, "init secrets provider")
+		}
+		if err := src.SetPasswords(ctx, passwords); err != nil {
+			return errors.Wrap(err, "set passwords in secrets provider")
+		}
+	}
+
+	return secret.NewKubernetesSource(r.Client, r.Scheme, cr).SetPasswords(ctx, passwords)
+}
+
+// ensureOrchestratorAPIPassword adds Orchestrator API password to the users
+// secrets created before the API required authentication. Password is added
+// to the internal secret as well since it's not a password rotation.
+func (r *PerconaServerMySQLReconciler) ensureOrchestratorAPIPassword(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	src secret.Source,
+	passwords map[string][]byte,
+) error {
+	key := string(apiv1alpha1.UserOrchestratorAPI)
+	if _, ok := passwords[key]; ok {
 		return nil
 	}
 
-	secret, err := secret.GeneratePasswordsSecret(cr.Spec.SecretsName, cr.Namespace)
+	pass, err := secret.GeneratePass()
 	if err != nil {
-		return errors.Wrap(err, "generate passwords")
+		return errors.Wrap(err, "generate password")
 	}
 
-	if err := k8s.EnsureObject(ctx, r.Client, cr, secret, r.Scheme); err != nil {
-		return errors.Wrapf(err, "create secret %s", cr.Spec.SecretsName)
+	internalSecret := &corev1.Secret{}
+	nn := types.NamespacedName{Name: cr.InternalSecretName(), Namespace: cr.Namespace}
+	if err := r.Client.Get(ctx, nn, internalSecret); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "get Secret/%s", nn.Name)
+	} else if err == nil {
+		if _, ok := internalSecret.Data[key]; !ok {
+			internalSecret.Data[key] = pass
+			if err := r.Client.Update(ctx, internalSecret); err != nil {
+				return errors.Wrapf(err, "update Secret/%s", nn.Name)
+			}
+		}
 	}
 
-	return nil
+	passwords[key] = pass
+	return errors.Wrap(src.SetPasswords(ctx, passwords), "set passwords")
 }
 
 func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
@@ -239,11 +412,13 @@ func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *a
 		restartOrchestrator bool
 	)
 	updatedUsers := make([]mysql.User, 0)
+	changedUsers := make([]string, 0)
 	for user, pass := range secret.Data {
 		if bytes.Equal(pass, internalSecret.Data[user]) {
 			l.V(1).Info("User password is up to date", "user", user)
 			continue
 		}
+		changedUsers = append(changedUsers, user)
 
 		mysqlUser := mysql.User{
 			Username: apiv1alpha1.SystemUser(user),
@@ -261,6 +436,10 @@ func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *a
 			restartReplication = true
 		case apiv1alpha1.UserOrchestrator:
 			restartOrchestrator = true
+		case apiv1alpha1.UserOrchestratorAPI:
+			// Orchestrator API credentials are not stored in db. Orchestrator
+			// is restarted with the new password after internal secret is updated.
+			continue
 		case apiv1alpha1.UserRoot:
 			mysqlUser.Hosts = append(mysqlUser.Hosts, "localhost")
 		case apiv1alpha1.UserClusterCheck, apiv1alpha1.UserXtraBackup:
@@ -277,70 +456,102 @@ func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *a
 		return errors.Wrap(err, "get operator password")
 	}
 
-	orcHost := orchestrator.APIHost(cr)
-	primary, err := orchestrator.ClusterPrimary(ctx, orcHost, cr.ClusterHint())
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
 	if err != nil {
 		return errors.Wrap(err, "get cluster primary")
 	}
 	l.V(1).Info("Got cluster primary", "primary", primary)
 	primaryHost := getPrimaryHostname(primary, cr)
 
-	um, err := users.NewManager(apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
+	um, err := newUserManager(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
 	if err != nil {
 		return errors.Wrap(err, "init user manager")
 	}
 	defer um.Close()
 
+	replicas := make([]string, 0, len(primary.Replicas))
+	replicaPorts := make(map[string]int32, len(primary.Replicas))
+	for _, replica := range primary.Replicas {
+		replicas = append(replicas, replica.Hostname)
+		replicaPorts[replica.Hostname] = replica.Port
+	}
+
+	startReplication := func(ctx context.Context, hostname string) error {
+		if err := orchestrator.StartReplication(ctx, orcAPI, hostname, replicaPorts[hostname]); err != nil {
+			return errors.Wrapf(err, "start replication on %s", hostname)
+		}
+
+		l.V(1).Info("Started replication on replica", "hostname", hostname, "port", replicaPorts[hostname])
+
+		return nil
+	}
+
 	if restartReplication {
-		db, err := replicator.NewReplicator(apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
+		db, err := newReplicator(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
 		if err != nil {
 			return errors.Wrap(err, "open connection to primary")
 		}
+		defer db.Close()
 
 		// We're disabling semi-sync replication on primary to avoid LockedSemiSyncMaster.
-		if err := db.SetSemiSyncSource(false); err != nil {
+		// It's enabled back by reconcileReplicationSemiSync.
+		if err := db.SetSemiSyncSource(ctx, false); err != nil {
 			return errors.Wrap(err, "set semi_sync wait count")
 		}
 
-		g, gCtx := errgroup.WithContext(context.Background())
-		for _, replica := range primary.Replicas {
-			hostname := replica.Hostname
-			port := replica.Port
-			g.Go(func() error {
-				repDb, err := replicator.NewReplicator(apiv1alpha1.UserOperator, operatorPass, hostname, port)
-				if err != nil {
-					return errors.Wrapf(err, "connect to replica %s", hostname)
-				}
+		stopReplication := func(ctx context.Context, hostname string) error {
+			port := replicaPorts[hostname]
+
+			repDb, err := newReplicator(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, hostname, mysql.DefaultAdminPort)
+			if err != nil {
+				return errors.Wrapf(err, "connect to replica %s", hostname)
+			}
+			defer repDb.Close()
+
+			if err := orchestrator.StopReplication(ctx, orcAPI, hostname, port); err != nil {
+				return errors.Wrapf(err, "stop replica %s", hostname)
+			}
+
+			status, _, err := repDb.ReplicationStatus(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "get replication status of %s", hostname)
+			}
 
-				if err := orchestrator.StopReplication(gCtx, orcHost, hostname, port); err != nil {
-					return errors.Wrapf(err, "stop replica %s", hostname)
+			for status == replicator.ReplicationStatusActive {
+				select {
+				case <-ctx.Done():
+					return errors.Wrapf(ctx.Err(), "wait replication to stop on %s", hostname)
+				case <-time.After(250 * time.Millisecond):
 				}
 
-				status, _, err := repDb.ReplicationStatus()
+				status, _, err = repDb.ReplicationStatus(ctx)
 				if err != nil {
 					return errors.Wrapf(err, "get replication status of %s", hostname)
 				}
+			}
 
-				for status == replicator.ReplicationStatusActive {
-					time.Sleep(250 * time.Millisecond)
-					status, _, err = repDb.ReplicationStatus()
-					if err != nil {
-						return errors.Wrapf(err, "get replication status of %s", hostname)
-					}
-				}
-
-				l.V(1).Info("Stopped replication on replica", "hostname", hostname, "port", port)
+			l.V(1).Info("Stopped replication on replica", "hostname", hostname, "port", port)
 
-				return nil
-			})
+			return nil
 		}
 
-		if err := g.Wait(); err != nil {
+		// replication is started back on the stopped replicas if any of them fails,
+		// the replication password is not changed yet
+		if _, err := parallel.RunOrUndo(ctx, parallel.DefaultLimit, replicas, stopReplication, startReplication); err != nil {
 			return errors.Wrap(err, "stop replication on replicas")
 		}
 	}
 
-	if err := um.UpdateUserPasswords(updatedUsers); err != nil {
+	if err := um.UpdateUserPasswords(ctx, updatedUsers); err != nil {
+		if restartReplication {
+			if undoErr := parallel.Undo(parallel.DefaultLimit, replicas, startReplication).Err(); undoErr != nil {
+				l.Error(undoErr, "failed to start replication after password update failure")
+			}
+		}
 		return errors.Wrapf(err, "update passwords")
 	}
 
@@ -352,38 +563,29 @@ func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *a
 				break
 			}
 		}
-		g, gCtx := errgroup.WithContext(context.Background())
-		for _, replica := range primary.Replicas {
-			hostname := replica.Hostname
-			port := replica.Port
-			g.Go(func() error {
-				db, err := replicator.NewReplicator(
-					apiv1alpha1.UserOperator,
-					operatorPass,
-					hostname,
-					mysql.DefaultAdminPort,
-				)
-				if err != nil {
-					return errors.Wrapf(err, "get db connection to %s", hostname)
-				}
-				defer db.Close()
-
-				pHost := getPrimaryHostname(primary, cr)
-				l.V(1).Info("Change replication source", "primary", pHost, "replica", hostname)
-				if err := db.ChangeReplicationSource(pHost, replicationPass, primary.Key.Port); err != nil {
-					return errors.Wrapf(err, "change replication source on %s", hostname)
-				}
 
-				if err := orchestrator.StartReplication(gCtx, orcHost, hostname, port); err != nil {
-					return errors.Wrapf(err, "start replication on %s", hostname)
-				}
+		changeReplicationSource := func(ctx context.Context, hostname string) error {
+			db, err := newReplicator(ctx, r.Client, cr,
+				apiv1alpha1.UserOperator,
+				operatorPass,
+				hostname,
+				mysql.DefaultAdminPort,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "get db connection to %s", hostname)
+			}
+			defer db.Close()
 
-				l.V(1).Info("Started replication on replica", "hostname", hostname, "port", port)
+			l.V(1).Info("Change replication source", "primary", primaryHost, "replica", hostname)
+			if err := db.ChangeReplicationSource(ctx, primaryHost, replicationPass, primary.Key.Port); err != nil {
+				return errors.Wrapf(err, "change replication source on %s", hostname)
+			}
 
-				return nil
-			})
+			return startReplication(ctx, hostname)
 		}
-		if err := g.Wait(); err != nil {
+
+		// failed replicas are retried on the next reconcile, internal secret is not updated
+		if err := parallel.Run(ctx, parallel.DefaultLimit, replicas, changeReplicationSource).Err(); err != nil {
 			return errors.Wrap(err, "start replication on replicas")
 		}
 	}
@@ -417,7 +619,7 @@ func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *a
 		return nil
 	}
 
-	if err := um.DiscardOldPasswords(updatedUsers); err != nil {
+	if err := um.DiscardOldPasswords(ctx, updatedUsers); err != nil {
 		return errors.Wrap(err, "discard old passwords")
 	}
 
@@ -430,289 +632,1915 @@ func (r *PerconaServerMySQLReconciler) reconcileUsers(ctx context.Context, cr *a
 
 	l.Info("Updated internal secret", "secretName", cr.InternalSecretName())
 
+	for _, user := range updatedUsers {
+		metrics.IncPasswordRotations(cr, string(user.Username))
+	}
+
+	if cr.Status.PasswordRotations == nil {
+		cr.Status.PasswordRotations = make(map[string]metav1.Time)
+	}
+	now := metav1.Now()
+	for _, user := range changedUsers {
+		cr.Status.PasswordRotations[user] = now
+	}
+
 	return nil
 }
 
-func (r *PerconaServerMySQLReconciler) ensureTLSSecret(
-	ctx context.Context,
-	cr *apiv1alpha1.PerconaServerMySQL,
-) error {
-	nn := types.NamespacedName{
-		Name:      cr.Spec.SSLSecretName,
-		Namespace: cr.Namespace,
-	}
+// reconcilePasswordRotation generates new passwords of the users in
+// spec.passwordRotation when their interval passes since the last password
+// change. The new passwords are applied by reconcileUsers.
+func (r *PerconaServerMySQLReconciler) reconcilePasswordRotation(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcilePasswordRotation")
 
-	if ok, err := k8s.ObjectExists(ctx, r.Client, nn, &corev1.Secret{}); err != nil {
-		return errors.Wrap(err, "check existence")
-	} else if ok {
+	pr := cr.Spec.PasswordRotation
+	if pr == nil || cr.Status.State != apiv1alpha1.StateReady || externalReplicationChannel(cr) != "" {
 		return nil
 	}
 
-	secret, err := secret.GenerateCertsSecret(ctx, cr)
-	if err != nil {
-		return errors.Wrap(err, "create SSL manually")
+	usersSecret := &corev1.Secret{}
+	nn := types.NamespacedName{Name: cr.Spec.SecretsName, Namespace: cr.Namespace}
+	if err := r.Client.Get(ctx, nn, usersSecret); err != nil {
+		return errors.Wrapf(err, "get Secret/%s", nn.Name)
 	}
 
-	if err := k8s.EnsureObject(ctx, r.Client, cr, secret, r.Scheme); err != nil {
-		return errors.Wrap(err, "create secret")
+	internalSecret := &corev1.Secret{}
+	nn.Name = cr.InternalSecretName()
+	if err := r.Client.Get(ctx, nn, internalSecret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "get Secret/%s", nn.Name)
 	}
 
-	return nil
-}
+	rotated := make([]string, 0)
+	for _, u := range pr.Users {
+		user := string(u)
 
-func (r *PerconaServerMySQLReconciler) reconcileDatabase(
-	ctx context.Context,
-	cr *apiv1alpha1.PerconaServerMySQL,
-) error {
-	configHash, err := r.reconcileMySQLConfiguration(ctx, cr)
-	if err != nil {
-		return errors.Wrap(err, "reconcile MySQL config")
+		// password change is not applied yet
+		if !bytes.Equal(usersSecret.Data[user], internalSecret.Data[user]) {
+			continue
+		}
+
+		last, ok := cr.Status.PasswordRotations[user]
+		if !ok {
+			last = cr.CreationTimestamp
+		}
+		due, err := rotationDue(pr, last.Time, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "check rotation schedule")
+		}
+		if !due {
+			continue
+		}
+
+		pass, err := secret.GeneratePass()
+		if err != nil {
+			return errors.Wrapf(err, "generate password of %s", user)
+		}
+		if usersSecret.Data == nil {
+			usersSecret.Data = make(map[string][]byte)
+		}
+		usersSecret.Data[user] = pass
+		rotated = append(rotated, user)
 	}
 
-	initImage, err := k8s.InitImage(ctx, r.Client)
-	if err != nil {
-		return errors.Wrap(err, "get init image")
+	if len(rotated) == 0 {
+		return nil
 	}
 
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.StatefulSet(cr, initImage, configHash), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile sts")
+	if err := r.storePasswords(ctx, cr, usersSecret.Data); err != nil {
+		return errors.Wrap(err, "store passwords")
 	}
 
-	return nil
-}
+	l.Info("Rotated passwords", "users", rotated)
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "PasswordRotation",
+		"Generated new passwords of %s", strings.Join(rotated, ", "))
 
-type Exposer interface {
-	Exposed() bool
-	Name(index string) string
-	Size() int32
-	Labels() map[string]string
-	Service(name string) *corev1.Service
+	return nil
 }
 
-func (r *PerconaServerMySQLReconciler) reconcileServicePerPod(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL, exposer Exposer) error {
-	_ = log.FromContext(ctx).WithName("reconcileServicePerPod")
-
-	if !exposer.Exposed() {
-		return nil
+// rotationDue returns true if the password changed last time should be
+// rotated by now according to the schedule or the interval of rotations
+func rotationDue(pr *apiv1alpha1.PasswordRotationSpec, last, now time.Time) (bool, error) {
+	if pr.Schedule == "" {
+		return now.Sub(last) >= pr.Interval.Duration, nil
 	}
 
-	size := int(exposer.Size())
-	svcNames := make(map[string]struct{}, size)
-	for i := 0; i < size; i++ {
-		svcName := exposer.Name(strconv.Itoa(i))
-		svc := exposer.Service(svcName)
-		svcNames[svc.Name] = struct{}{}
-
-		if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, svc, r.Scheme); err != nil {
-			return errors.Wrapf(err, "reconcile svc for pod %s", svc.Name)
-		}
+	sched, err := schedule.Parse(pr.Schedule)
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	return !sched.Next(last).After(now), nil
 }
 
-func (r *PerconaServerMySQLReconciler) reconcileMySQLServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
-	_ = log.FromContext(ctx).WithName("reconcileMySQLServices")
+// reconcileSystemUserGrants fixes drift of system user grants on the primary.
+// Grants are not changed while the primary replicates from outside of the
+// cluster, they are replicated from the source.
+func (r *PerconaServerMySQLReconciler) reconcileSystemUserGrants(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileSystemUserGrants")
 
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.PrimaryService(cr), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile primary svc")
+	if cr.Status.MySQL.State != apiv1alpha1.StateReady || externalReplicationChannel(cr) != "" {
+		return nil
 	}
 
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.UnreadyService(cr), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile unready svc")
+	um, err := primaryUserManager(ctx, r.Client, cr)
+	if err != nil {
+		return err
 	}
+	defer um.Close()
 
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.HeadlessService(cr), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile headless svc")
+	systemUsers := make([]string, 0, len(users.SystemUserGrants))
+	for user := range users.SystemUserGrants {
+		systemUsers = append(systemUsers, string(user))
 	}
+	sort.Strings(systemUsers)
 
-	exposer := mysql.Exposer(*cr)
-	if err := r.reconcileServicePerPod(ctx, cr, &exposer); err != nil {
-		return errors.Wrap(err, "reconcile service per pod")
+	for _, user := range systemUsers {
+		canonical := users.SystemUserGrants[apiv1alpha1.SystemUser(user)]
+		fixes, err := um.ReconcileGrants(ctx, mysql.User{
+			Username: apiv1alpha1.SystemUser(user),
+			Hosts:    canonical.Hosts,
+		}, canonical.Grants)
+		if err != nil {
+			return errors.Wrapf(err, "reconcile grants of %s", user)
+		}
+
+		for _, fix := range fixes {
+			l.Info("Fixed grants drift", "user", user, "fix", fix)
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, "GrantsDrift", "Grants of system user are fixed: %s", fix)
+		}
 	}
 
 	return nil
 }
 
-func (r *PerconaServerMySQLReconciler) reconcileMySQLConfiguration(
-	ctx context.Context,
-	cr *apiv1alpha1.PerconaServerMySQL,
-) (string, error) {
-	l := log.FromContext(ctx).WithName("reconcileMySQLConfiguration")
-
-	cmName := mysql.ConfigMapName(cr)
-	nn := types.NamespacedName{Name: cmName, Namespace: cr.Namespace}
+// reconcileBindingSecret writes connection details of the PerconaServerMySQLUser
+// in spec.binding.user to the binding Secret. The Secret is written after the
+// user controller applies the password, so the password in the Secret works.
+// Without spec.binding.user the Secret is deleted, e.g. the Secret with root
+// credentials written by previous versions of the operator.
+func (r *PerconaServerMySQLReconciler) reconcileBindingSecret(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileBindingSecret")
 
-	currCm := &corev1.ConfigMap{}
-	if err := r.Client.Get(ctx, nn, currCm); err != nil && !k8serrors.IsNotFound(err) {
-		return "", errors.Wrapf(err, "get ConfigMap/%s", cmName)
+	if cr.Spec.Binding.Enabled && cr.Spec.Binding.User == "" {
+		r.Recorder.Event(cr, corev1.EventTypeWarning, "BindingUserRequired",
+			"binding.user is required, credentials of system users are not written to the binding Secret")
 	}
 
-	// Cleanup if user removed the configuration from CR
-	if cr.Spec.MySQL.Configuration == "" {
-		exists, err := k8s.ObjectExists(ctx, r.Client, nn, currCm)
-		if err != nil {
-			return "", errors.Wrapf(err, "check if ConfigMap/%s exists", cmName)
+	if !cr.Spec.Binding.Enabled || cr.Spec.Binding.User == "" {
+		if err := deleteBindingSecret(ctx, r.Client, cr.Namespace, cr.Status.Binding); err != nil {
+			return err
 		}
+		cr.Status.Binding = nil
+		return nil
+	}
 
-		if !exists || !metav1.IsControlledBy(currCm, cr) {
-			return "", nil
+	if cr.Status.Binding != nil && cr.Status.Binding.Name != cr.Spec.Binding.SecretName {
+		if err := deleteBindingSecret(ctx, r.Client, cr.Namespace, cr.Status.Binding); err != nil {
+			return err
 		}
+		cr.Status.Binding = nil
+	}
 
-		if err := r.Client.Delete(ctx, currCm); err != nil {
-			return "", errors.Wrapf(err, "delete ConfigMaps/%s", cmName)
-		}
+	user := &apiv1alpha1.PerconaServerMySQLUser{}
+	nn := types.NamespacedName{Name: cr.Spec.Binding.User, Namespace: cr.Namespace}
+	if err := r.Client.Get(ctx, nn, user); err != nil {
+		return errors.Wrapf(err, "get PerconaServerMySQLUser/%s", nn.Name)
+	}
+	if user.Spec.ClusterName != cr.Name {
+		return errors.Errorf("PerconaServerMySQLUser/%s belongs to cluster %s", nn.Name, user.Spec.ClusterName)
+	}
+	if err := user.CheckNSetDefaults(); err != nil {
+		return errors.Wrapf(err, "check PerconaServerMySQLUser/%s", nn.Name)
+	}
 
-		l.Info("ConfigMap deleted", "name", cmName)
+	passSecret := &corev1.Secret{}
+	nn.Name = user.Spec.PasswordSecretRef.Name
+	if err := r.Client.Get(ctx, nn, passSecret); err != nil {
+		return errors.Wrapf(err, "get Secret/%s", nn.Name)
+	}
+	pass, ok := passSecret.Data[user.Spec.PasswordSecretRef.Key]
+	if !ok {
+		return errors.Errorf("Secret/%s has no %s key", nn.Name, user.Spec.PasswordSecretRef.Key)
+	}
 
-		return "", nil
+	if user.Status.State != apiv1alpha1.UserStateReady || user.Status.PasswordHash != userPasswordHash(user, pass) {
+		l.V(1).Info("Waiting for the user to be applied", "user", user.Name)
+		return nil
 	}
 
-	cm := k8s.ConfigMap(cmName, cr.Namespace, mysql.CustomConfigKey, cr.Spec.MySQL.Configuration)
-	if !reflect.DeepEqual(currCm.Data, cm.Data) {
-		if err := k8s.EnsureObject(ctx, r.Client, cr, cm, r.Scheme); err != nil {
-			return "", errors.Wrapf(err, "ensure ConfigMap/%s", cmName)
-		}
+	ca, err := clusterCA(ctx, r.Client, cr)
+	if err != nil {
+		return err
+	}
 
-		l.Info("ConfigMap updated", "name", cmName, "data", cm.Data)
+	database := ""
+	if len(user.Spec.Databases) > 0 {
+		database = user.Spec.Databases[0]
 	}
 
-	d := struct{ Data map[string]string }{Data: cm.Data}
-	data, err := json.Marshal(d)
-	if err != nil {
-		return "", errors.Wrap(err, "marshal configmap data to json")
+	binding := mysql.BindingSecret(cr, cr.Spec.Binding.SecretName, user.Spec.Name, string(pass), database, ca)
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, binding, r.Scheme); err != nil {
+		return errors.Wrapf(err, "ensure Secret/%s", binding.Name)
+	}
+	cr.Status.Binding = &corev1.LocalObjectReference{Name: binding.Name}
+
+	return nil
+}
+
+// clusterCA returns CA certificate of the cluster TLS secret
+func clusterCA(ctx context.Context, cl client.Reader, cr *apiv1alpha1.PerconaServerMySQL) ([]byte, error) {
+	tlsSecret := &corev1.Secret{}
+	nn := types.NamespacedName{Name: cr.Spec.SSLSecretName, Namespace: cr.Namespace}
+	if err := cl.Get(ctx, nn, tlsSecret); err != nil {
+		return nil, errors.Wrapf(err, "get Secret/%s", nn.Name)
+	}
+
+	return tlsSecret.Data["ca.crt"], nil
+}
+
+// deleteBindingSecret deletes the binding Secret which isn't needed anymore
+func deleteBindingSecret(ctx context.Context, cl client.Client, namespace string, ref *corev1.LocalObjectReference) error {
+	if ref == nil {
+		return nil
+	}
+
+	binding := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: namespace}}
+	return errors.Wrapf(client.IgnoreNotFound(cl.Delete(ctx, binding)), "delete Secret/%s", ref.Name)
+}
+
+func (r *PerconaServerMySQLReconciler) ensureTLSSecret(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+) error {
+	nn := types.NamespacedName{
+		Name:      cr.Spec.SSLSecretName,
+		Namespace: cr.Namespace,
+	}
+
+	current := &corev1.Secret{}
+	if ok, err := k8s.ObjectExists(ctx, r.Client, nn, current); err != nil {
+		return errors.Wrap(err, "check existence")
+	} else if ok {
+		return r.renewTLSSecret(ctx, cr, current)
+	}
+
+	secret, err := secret.GenerateCertsSecret(ctx, cr)
+	if err != nil {
+		return errors.Wrap(err, "create SSL manually")
+	}
+
+	if err := k8s.EnsureObject(ctx, r.Client, cr, secret, r.Scheme); err != nil {
+		return errors.Wrap(err, "create secret")
+	}
+
+	return nil
+}
+
+// renewTLSSecret regenerates the TLS secret created by the operator if its
+// certificate isn't issued for some of the cluster host names, e.g. after a
+// replica pool is added. The previous CA is kept in ca.crt until pods are
+// restarted with the new certificate. Certificates provided by the user are
+// never changed.
+func (r *PerconaServerMySQLReconciler) renewTLSSecret(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	current *corev1.Secret,
+) error {
+	l := log.FromContext(ctx).WithName("renewTLSSecret")
+
+	owned := metav1.IsControlledBy(current, cr)
+
+	missing, err := secret.MissingDNSNames(current.Data["tls.crt"], secret.DNSNames(cr))
+	if err != nil {
+		if !owned {
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, "TLSCertificateInvalid",
+				"certificate in Secret/%s can't be checked: %v", current.Name, err)
+			return nil
+		}
+		return errors.Wrapf(err, "check certificate of Secret/%s", current.Name)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if !owned {
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "TLSCertificateNamesMissing",
+			"certificate in Secret/%s isn't issued for %s", current.Name, strings.Join(missing, ", "))
+		return nil
+	}
+
+	renewed, err := secret.GenerateCertsSecret(ctx, cr)
+	if err != nil {
+		return errors.Wrap(err, "generate certificates")
+	}
+	if err := secret.AppendCA(renewed, current.Data["ca.crt"]); err != nil {
+		return errors.Wrap(err, "keep previous CA")
+	}
+
+	orig := current.DeepCopy()
+	current.Data = renewed.Data
+	if err := r.Client.Patch(ctx, current, client.MergeFrom(orig)); err != nil {
+		return errors.Wrapf(err, "update Secret/%s", current.Name)
+	}
+
+	l.Info("TLS certificate renewed", "secret", current.Name, "missing", missing)
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "TLSCertificateRenewed",
+		"certificate in Secret/%s is renewed for %s", current.Name, strings.Join(missing, ", "))
+
+	hash := fmt.Sprintf("%x", md5.Sum(current.Data["tls.crt"]))
+	names := []types.NamespacedName{mysql.NamespacedName(cr), orchestrator.NamespacedName(cr)}
+	for _, pool := range cr.MySQLSpec().ReplicaPools {
+		names = append(names, mysql.ReplicaPoolNamespacedName(cr, pool.Name))
+	}
+	for _, nn := range names {
+		sts := &appsv1.StatefulSet{}
+		if err := r.Client.Get(ctx, nn, sts); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "get StatefulSet/%s", nn.Name)
+		}
+		if err := k8s.RolloutRestart(ctx, r.Client, sts, apiv1alpha1.AnnotationTLSHash, hash); err != nil {
+			return errors.Wrapf(err, "restart StatefulSet/%s", nn.Name)
+		}
+	}
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileDatabase(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+) error {
+	configHash, err := r.reconcileMySQLConfiguration(ctx, cr)
+	if err != nil {
+		return errors.Wrap(err, "reconcile MySQL config")
+	}
+
+	initImage, err := k8s.InitImage(ctx, r.APIReader)
+	if err != nil {
+		return errors.Wrap(err, "get init image")
+	}
+
+	sts := mysql.StatefulSet(cr, initImage, configHash)
+
+	currentSts := &appsv1.StatefulSet{}
+	exists, err := k8s.ObjectExists(ctx, r.Client, mysql.NamespacedName(cr), currentSts)
+	if err != nil {
+		return errors.Wrap(err, "check if sts exists")
+	}
+	if exists && currentSts.Spec.Replicas != nil && *currentSts.Spec.Replicas > *sts.Spec.Replicas {
+		ok, err := r.prepareScaleDown(ctx, cr, *currentSts.Spec.Replicas)
+		if err != nil {
+			return errors.Wrap(err, "prepare scale down")
+		}
+		if !ok {
+			// keep current replicas until it's safe to remove pods
+			sts.Spec.Replicas = currentSts.Spec.Replicas
+		}
+	}
+
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, sts, r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile sts")
+	}
+
+	for i := range cr.MySQLSpec().ReplicaPools {
+		pool := &cr.MySQLSpec().ReplicaPools[i]
+		poolSts := mysql.ReplicaPoolStatefulSet(cr, pool, initImage, configHash)
+		if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, poolSts, r.Scheme); err != nil {
+			return errors.Wrapf(err, "reconcile sts of replica pool %s", pool.Name)
+		}
+	}
+
+	return nil
+}
+
+// prepareScaleDown makes sure that MySQL pods with ordinals from
+// spec.mysql.size to current replicas can be removed. If one of them is the
+// primary, graceful switchover to the remaining pod is done first, the
+// candidate is chosen by promotion rules like on failover. Replication
+// is stopped on all pods that are going to be removed, if it fails on some of
+// them it's started back on the others.
+// It returns true if the StatefulSet can be scaled down.
+func (r *PerconaServerMySQLReconciler) prepareScaleDown(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	currentSize int32,
+) (bool, error) {
+	l := log.FromContext(ctx).WithName("prepareScaleDown")
+
+	size := int(cr.MySQLSpec().Size)
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return false, errors.Wrap(err, "get Orchestrator API client")
+	}
+
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return false, errors.Wrap(err, "get cluster primary")
+	}
+
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return false, errors.Wrap(err, "get cluster instances")
+	}
+
+	if idx, err := ordinal(primary.Alias); err != nil {
+		return false, errors.Wrapf(err, "get ordinal of primary %s", primary.Alias)
+	} else if idx >= size {
+		rules, err := r.promotionRules(ctx, cr, instances)
+		if err != nil {
+			return false, errors.Wrap(err, "get promotion rules")
+		}
+
+		candidate := switchoverCandidate(instances, rules, size)
+		if candidate == nil {
+			return false, errors.Errorf("no candidate to replace primary %s", primary.Alias)
+		}
+
+		l.Info("Primary will be removed by scale down, switching over",
+			"primary", primary.Alias, "candidate", candidate.Alias)
+
+		err = orchestrator.GracefulMasterTakeover(ctx, orcAPI, cr.ClusterHint(), candidate.Key.Hostname, candidate.Key.Port)
+		if err != nil {
+			return false, errors.Wrapf(err, "switchover to %s", candidate.Alias)
+		}
+
+		return false, nil
+	}
+
+	removed := make(map[string]*orchestrator.Instance)
+	aliases := make([]string, 0)
+	for _, inst := range instances {
+		if mysql.IsReplicaPoolPod(cr, inst.Alias) {
+			continue
+		}
+		i, err := ordinal(inst.Alias)
+		if err != nil || i < size || i >= int(currentSize) {
+			continue
+		}
+
+		removed[inst.Alias] = inst
+		aliases = append(aliases, inst.Alias)
+	}
+
+	stopReplication := func(ctx context.Context, alias string) error {
+		inst := removed[alias]
+		if err := orchestrator.StopReplication(ctx, orcAPI, inst.Key.Hostname, inst.Key.Port); err != nil {
+			return errors.Wrapf(err, "stop replication on %s", alias)
+		}
+
+		l.Info("Stopped replication before scale down", "instance", alias)
+
+		return nil
+	}
+	startReplication := func(ctx context.Context, alias string) error {
+		inst := removed[alias]
+		return errors.Wrapf(orchestrator.StartReplication(ctx, orcAPI, inst.Key.Hostname, inst.Key.Port),
+			"start replication on %s", alias)
+	}
+
+	// pods keep replicating until all of them can be removed
+	if _, err := parallel.RunOrUndo(ctx, parallel.DefaultLimit, aliases, stopReplication, startReplication); err != nil {
+		return false, errors.Wrap(err, "stop replication before scale down")
+	}
+
+	return true, nil
+}
+
+// ordinal returns ordinal of the StatefulSet pod or PVC by its name
+func ordinal(name string) (int, error) {
+	return strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+}
+
+type Exposer interface {
+	Exposed() bool
+	Name(index string) string
+	Size() int32
+	Labels() map[string]string
+	Service(name string) *corev1.Service
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileServicePerPod(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL, exposer Exposer) error {
+	_ = log.FromContext(ctx).WithName("reconcileServicePerPod")
+
+	if !exposer.Exposed() {
+		return nil
+	}
+
+	size := int(exposer.Size())
+	svcNames := make(map[string]struct{}, size)
+	for i := 0; i < size; i++ {
+		svcName := exposer.Name(strconv.Itoa(i))
+		svc := exposer.Service(svcName)
+		svcNames[svc.Name] = struct{}{}
+
+		if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, svc, r.Scheme); err != nil {
+			return errors.Wrapf(err, "reconcile svc for pod %s", svc.Name)
+		}
+	}
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileMySQLServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	_ = log.FromContext(ctx).WithName("reconcileMySQLServices")
+
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.PrimaryService(cr), r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile primary svc")
+	}
+
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.UnreadyService(cr), r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile unready svc")
+	}
+
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.HeadlessService(cr), r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile headless svc")
+	}
+
+	for i := range cr.MySQLSpec().ReplicaPools {
+		pool := &cr.MySQLSpec().ReplicaPools[i]
+		if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, mysql.ReplicaPoolService(cr, pool), r.Scheme); err != nil {
+			return errors.Wrapf(err, "reconcile svc of replica pool %s", pool.Name)
+		}
+	}
+
+	exposer := mysql.Exposer(*cr)
+	if err := r.reconcileServicePerPod(ctx, cr, &exposer); err != nil {
+		return errors.Wrap(err, "reconcile service per pod")
+	}
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileMySQLConfiguration(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+) (string, error) {
+	l := log.FromContext(ctx).WithName("reconcileMySQLConfiguration")
+
+	cmName := mysql.ConfigMapName(cr)
+	nn := types.NamespacedName{Name: cmName, Namespace: cr.Namespace}
+
+	currCm := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, nn, currCm); err != nil && !k8serrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "get ConfigMap/%s", cmName)
+	}
+
+	// Cleanup if user removed the configuration from CR
+	if cr.Spec.MySQL.Configuration == "" {
+		exists, err := k8s.ObjectExists(ctx, r.Client, nn, currCm)
+		if err != nil {
+			return "", errors.Wrapf(err, "check if ConfigMap/%s exists", cmName)
+		}
+
+		if !exists || !metav1.IsControlledBy(currCm, cr) {
+			return "", nil
+		}
+
+		if err := r.Client.Delete(ctx, currCm); err != nil {
+			return "", errors.Wrapf(err, "delete ConfigMaps/%s", cmName)
+		}
+
+		l.Info("ConfigMap deleted", "name", cmName)
+
+		return "", nil
+	}
+
+	cm := k8s.ConfigMap(cmName, cr.Namespace, mysql.CustomConfigKey, cr.Spec.MySQL.Configuration)
+	if !reflect.DeepEqual(currCm.Data, cm.Data) {
+		if err := k8s.EnsureObject(ctx, r.Client, cr, cm, r.Scheme); err != nil {
+			return "", errors.Wrapf(err, "ensure ConfigMap/%s", cmName)
+		}
+
+		l.Info("ConfigMap updated", "name", cmName, "data", cm.Data)
+	}
+
+	d := struct{ Data map[string]string }{Data: cm.Data}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal configmap data to json")
 	}
 
 	return fmt.Sprintf("%x", md5.Sum(data)), nil
 }
 
-func (r *PerconaServerMySQLReconciler) reconcileOrchestrator(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
-	l := log.FromContext(ctx).WithName("reconcileOrchestrator")
+func (r *PerconaServerMySQLReconciler) reconcileOrchestrator(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileOrchestrator")
+
+	configSecret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: orchestrator.ConfigSecretName(cr), Namespace: cr.Namespace}, configSecret)
+	if client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "get config secret")
+	}
+
+	existingNodes := make([]string, 0)
+	if !k8serrors.IsNotFound(err) {
+		cfg, ok := configSecret.Data[orchestrator.ConfigFileName]
+		if !ok {
+			return errors.Errorf("key %s not found in Secret", orchestrator.ConfigFileName)
+		}
+
+		config := make(map[string]interface{}, 0)
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return errors.Wrap(err, "unmarshal Secret data to json")
+		}
+
+		nodes, ok := config["RaftNodes"].([]interface{})
+		if !ok {
+			return errors.New("key RaftNodes not found in Secret")
+		}
+
+		for _, v := range nodes {
+			existingNodes = append(existingNodes, v.(string))
+		}
+	}
+
+	if recreated, err := r.recreateOrchestratorStatefulSet(ctx, cr); err != nil || recreated {
+		return err
+	}
+
+	apiPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOrchestratorAPI)
+	if err != nil {
+		return errors.Wrap(err, "get orchestrator API password")
+	}
+
+	// token authenticates requests to the failover hook, it's kept in the configuration Secret
+	hookToken := configSecret.Data[orchestrator.FailoverHookTokenKey]
+	if len(hookToken) == 0 {
+		hookToken, err = secret.GeneratePass()
+		if err != nil {
+			return errors.Wrap(err, "generate failover hook token")
+		}
+	}
+
+	configData, err := orchestrator.ConfigData(cr, r.failoverHookURL(ctx), hookToken, apiPass)
+	if err != nil {
+		return errors.Wrap(err, "get config data")
+	}
+
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, orchestrator.ConfigSecret(cr, configData), r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile config Secret")
+	}
+
+	// configuration was stored in ConfigMap by previous versions
+	legacyConfig := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, orchestrator.NamespacedName(cr), legacyConfig); err == nil {
+		if err := r.Client.Delete(ctx, legacyConfig); client.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, "delete legacy config ConfigMap")
+		}
+	} else if !k8serrors.IsNotFound(err) {
+		return errors.Wrap(err, "get legacy config ConfigMap")
+	}
+
+	initImage, err := k8s.InitImage(ctx, r.APIReader)
+	if err != nil {
+		return errors.Wrap(err, "get init image")
+	}
+
+	// orchestrator reads configuration on start, pods are restarted if configuration is changed
+	configHash := fmt.Sprintf("%x", md5.Sum(configData[orchestrator.ConfigFileName]))
+
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, orchestrator.StatefulSet(cr, initImage, configHash), r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile StatefulSet")
+	}
+
+	raftNodes := orchestrator.RaftNodes(cr)
+	if len(existingNodes) == 0 || len(existingNodes) == len(raftNodes) {
+		return nil
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	g, gCtx := errgroup.WithContext(context.Background())
+
+	if len(raftNodes) > len(existingNodes) {
+		newPeers := util.Difference(raftNodes, existingNodes)
+
+		for _, peer := range newPeers {
+			p := peer
+			g.Go(func() error {
+				return orchestrator.AddPeer(gCtx, orcAPI, p)
+			})
+		}
+
+		l.Error(g.Wait(), "Orchestrator add peers", "peers", newPeers)
+	} else {
+		oldPeers := util.Difference(existingNodes, raftNodes)
+
+		for _, peer := range oldPeers {
+			p := peer
+			g.Go(func() error {
+				return orchestrator.RemovePeer(gCtx, orcAPI, p)
+			})
+		}
+
+		l.Error(g.Wait(), "Orchestrator remove peers", "peers", oldPeers)
+	}
+
+	return nil
+}
+
+// recreateOrchestratorStatefulSet deletes orchestrator StatefulSet if its
+// volume claim templates are changed, e.g. StatefulSet was created without data
+// volume by previous operator versions. Volume claim templates can't be updated,
+// so StatefulSet is deleted leaving pods orphaned and is created again on the next
+// reconcile. Pods are adopted and restarted by the new StatefulSet.
+func (r *PerconaServerMySQLReconciler) recreateOrchestratorStatefulSet(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) (bool, error) {
+	l := log.FromContext(ctx).WithName("recreateOrchestratorStatefulSet")
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, orchestrator.NamespacedName(cr), sts); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	if sts.DeletionTimestamp != nil {
+		return true, nil
+	}
+
+	current := make([]string, 0, len(sts.Spec.VolumeClaimTemplates))
+	for _, pvc := range sts.Spec.VolumeClaimTemplates {
+		current = append(current, pvc.Name)
+	}
+
+	desired := make([]string, 0)
+	for _, pvc := range orchestrator.StatefulSet(cr, "", "").Spec.VolumeClaimTemplates {
+		desired = append(desired, pvc.Name)
+	}
+
+	if reflect.DeepEqual(current, desired) {
+		return false, nil
+	}
+
+	l.Info("Volume claim templates are changed, recreating StatefulSet", "current", current, "desired", desired)
+
+	if err := r.Delete(ctx, sts, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+		return false, errors.Wrapf(err, "delete StatefulSet %s", sts.Name)
+	}
+
+	return true, nil
+}
+
+// failoverHookURL returns URL of the failover hook endpoint of the operator.
+// It returns empty string if the hook is disabled.
+func (r *PerconaServerMySQLReconciler) failoverHookURL(ctx context.Context) string {
+	if r.FailoverHook == nil {
+		return ""
+	}
+
+	ns, err := k8s.DefaultAPINamespace()
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("failed to get operator namespace, failover hook is disabled", "error", err.Error())
+		return ""
+	}
+
+	return fmt.Sprintf("http://%s.%s.svc:%d/failover", FailoverHookServiceName, ns, FailoverHookServicePort)
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileOrchestratorServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, orchestrator.Service(cr), r.Scheme); err != nil {
+		return errors.Wrap(err, "reconcile Service")
+	}
+
+	exposer := orchestrator.Exposer(*cr)
+	if err := r.reconcileServicePerPod(ctx, cr, &exposer); err != nil {
+		return errors.Wrap(err, "reconcile service per pod")
+	}
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	if err := r.reconcileMySQLServices(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile MySQL services")
+	}
+
+	if err := r.reconcileOrchestratorServices(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile Orchestrator services")
+	}
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) reconcileReplication(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileReplication")
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, orchestrator.NamespacedName(cr), sts); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if sts.Status.ReadyReplicas == 0 {
+		l.Info("orchestrator is not ready. skip", "ready", sts.Status.ReadyReplicas)
+		return nil
+	}
+
+	if err := reconcileReplicationPrimaryPod(ctx, r.Client, cr); err != nil {
+		return errors.Wrap(err, "reconcile primary pod")
+	}
+	if err := r.reconcileReplicationSource(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile replication source")
+	}
+	if err := r.reconcileMigration(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile migration")
+	}
+	if err := reconcileReplicationSemiSync(ctx, r.Client, cr); err != nil {
+		return errors.Wrap(err, "reconcile semi-sync")
+	}
+	if err := r.reconcileReplicaPools(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile replica pools")
+	}
+	if err := r.reconcileReplicaPoolInstances(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile replica pool instances")
+	}
+	if err := r.reconcilePromotionRules(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile promotion rules")
+	}
+	if err := r.reconcileHeartbeat(ctx, cr); err != nil {
+		return errors.Wrap(err, "reconcile heartbeat")
+	}
+
+	return nil
+}
+
+// reconcileReplicationSource configures replication from spec.replicationSource
+// on the primary and keeps it super_read_only. The channel is removed from other
+// instances, e.g. from the old primary after failover. When the cluster is
+// promoted, the channel is removed from the primary and it becomes writable.
+func (r *PerconaServerMySQLReconciler) reconcileReplicationSource(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileReplicationSource")
+
+	rs := cr.Spec.ReplicationSource
+	if rs == nil {
+		return nil
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster primary")
+	}
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	operatorPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOperator)
+	if err != nil {
+		return errors.Wrap(err, "get operator password")
+	}
+
+	for _, inst := range instances {
+		if inst.Key == primary.Key || mysql.IsReplicaPoolPod(cr, inst.Alias) {
+			continue
+		}
+
+		// unavailable replicas are checked when they are back
+		if err := r.removeReplicationChannel(ctx, cr, operatorPass, inst.Key.Hostname, replicator.SourceChannelName); err != nil {
+			l.Error(err, "failed to remove replication source channel", "host", inst.Key.Hostname)
+		}
+	}
+
+	primaryHost := getPrimaryHostname(primary, cr)
+	db, err := newReplicator(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %v", primaryHost)
+	}
+	defer db.Close()
+
+	exists, err := db.ChannelExists(ctx, replicator.SourceChannelName)
+	if err != nil {
+		return errors.Wrap(err, "check replication source channel")
+	}
+
+	if rs.Promote {
+		if !exists {
+			return nil
+		}
+
+		if err := db.ResetChannelReplication(ctx, replicator.SourceChannelName); err != nil {
+			return errors.Wrap(err, "reset replication source channel")
+		}
+		if err := db.DisableReadonly(ctx); err != nil {
+			return errors.Wrapf(err, "make %s writable", primary.Alias)
+		}
+
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "ReplicationSourcePromoted",
+			"%s stopped replication from the source cluster and is writable", primary.Alias)
+		l.Info("Cluster is promoted", "primary", primary.Alias)
+
+		return nil
+	}
+
+	// Orchestrator makes the new primary writable after failover
+	superReadOnly, err := db.IsSuperReadonly(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check super_read_only")
+	}
+	if !superReadOnly {
+		if err := db.EnableSuperReadonly(ctx); err != nil {
+			return errors.Wrapf(err, "enable super_read_only on %s", primary.Alias)
+		}
+		l.Info("Primary of replica cluster is switched to super_read_only", "primary", primary.Alias)
+	}
+
+	if !exists {
+		secret := &corev1.Secret{}
+		nn := types.NamespacedName{Name: rs.CredentialsSecret, Namespace: cr.Namespace}
+		if err := r.Client.Get(ctx, nn, secret); err != nil {
+			return errors.Wrapf(err, "get secret %s", rs.CredentialsSecret)
+		}
+		pass, ok := secret.Data[string(apiv1alpha1.UserReplication)]
+		if !ok {
+			return errors.Errorf("secret %s has no %s key", rs.CredentialsSecret, apiv1alpha1.UserReplication)
+		}
+
+		source := rs.Hosts[0]
+		if err := db.ChangeChannelSource(ctx, replicator.SourceChannelName, source.Host, string(pass), source.Port); err != nil {
+			return errors.Wrap(err, "configure replication source channel")
+		}
+		l.Info("Configured replication from source cluster", "primary", primary.Alias, "source", source.Host)
+	}
+
+	if err := reconcileFailoverSources(ctx, db, rs.Hosts); err != nil {
+		return errors.Wrap(err, "reconcile failover sources")
+	}
+
+	status, _, err := db.ChannelReplicationStatus(ctx, replicator.SourceChannelName)
+	if err != nil {
+		return errors.Wrap(err, "get replication source channel status")
+	}
+	if status != replicator.ReplicationStatusActive {
+		if err := db.StartChannelReplication(ctx, replicator.SourceChannelName); err != nil {
+			return errors.Wrap(err, "start replication from source cluster")
+		}
+		l.Info("Started replication from source cluster", "primary", primary.Alias)
+	}
+
+	return nil
+}
+
+// reconcileFailoverSources makes asynchronous connection failover sources of
+// the replication source channel match spec.replicationSource.hosts
+func reconcileFailoverSources(ctx context.Context, db replicator.Replicator, hosts []apiv1alpha1.ReplicationSourceHost) error {
+	current, err := db.FailoverSources(ctx, replicator.SourceChannelName)
+	if err != nil {
+		return errors.Wrap(err, "get failover sources")
+	}
+
+	desired := make(map[string]replicator.FailoverSource, len(hosts))
+	for _, h := range hosts {
+		desired[fmt.Sprintf("%s:%d", h.Host, h.Port)] = replicator.FailoverSource{Host: h.Host, Port: h.Port, Weight: h.Weight}
+	}
+
+	for _, src := range current {
+		key := fmt.Sprintf("%s:%d", src.Host, src.Port)
+		if d, ok := desired[key]; ok && d.Weight == src.Weight {
+			delete(desired, key)
+			continue
+		}
+		if err := db.DeleteFailoverSource(ctx, replicator.SourceChannelName, src); err != nil {
+			return err
+		}
+	}
+
+	for _, src := range desired {
+		if err := db.AddFailoverSource(ctx, replicator.SourceChannelName, src); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) removeReplicationChannel(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	operatorPass, host, channel string,
+) error {
+	db, err := newReplicator(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, host, mysql.DefaultAdminPort)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %v", host)
+	}
+	defer db.Close()
+
+	exists, err := db.ChannelExists(ctx, channel)
+	if err != nil || !exists {
+		return err
+	}
+
+	return db.ResetChannelReplication(ctx, channel)
+}
+
+// externalReplicationChannel returns the channel which the primary uses to replicate
+// from outside of the cluster or empty string if it doesn't. Primary is read only
+// while it replicates from such channel.
+func externalReplicationChannel(cr *apiv1alpha1.PerconaServerMySQL) string {
+	switch {
+	case cr.IsReplicaCluster():
+		return replicator.SourceChannelName
+	case cr.IsMigrating():
+		return replicator.MigrationChannelName
+	default:
+		return ""
+	}
+}
+
+// reconcileMigration replicates the primary from spec.migration after bootstrap
+// cloned it and reports the lag. On cutover, it waits until the primary applies
+// all transactions executed on the external server, removes the channel and
+// makes the primary writable.
+func (r *PerconaServerMySQLReconciler) reconcileMigration(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileMigration")
+
+	m := cr.Spec.Migration
+	if m == nil || !cr.IsMigrating() {
+		return nil
+	}
+
+	if cr.Status.Migration == nil {
+		cr.Status.Migration = &apiv1alpha1.MigrationStatus{State: apiv1alpha1.MigrationStateCloning}
+	}
+	status := cr.Status.Migration
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster primary")
+	}
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	operatorPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOperator)
+	if err != nil {
+		return errors.Wrap(err, "get operator password")
+	}
+
+	// old primary keeps the channel after failover
+	for _, inst := range instances {
+		if inst.Key == primary.Key || mysql.IsReplicaPoolPod(cr, inst.Alias) {
+			continue
+		}
+
+		if err := r.removeReplicationChannel(ctx, cr, operatorPass, inst.Key.Hostname, replicator.MigrationChannelName); err != nil {
+			l.Error(err, "failed to remove migration channel", "host", inst.Key.Hostname)
+		}
+	}
+
+	primaryHost := getPrimaryHostname(primary, cr)
+	db, err := newReplicator(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %v", primaryHost)
+	}
+	defer db.Close()
+
+	cloned, err := db.IsCloned(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "check if %s is cloned", primary.Alias)
+	}
+	if !cloned {
+		l.Info("Waiting for the primary to be cloned from migration source", "primary", primary.Alias)
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	nn := types.NamespacedName{Name: m.CredentialsSecret, Namespace: cr.Namespace}
+	if err := r.Client.Get(ctx, nn, secret); err != nil {
+		return errors.Wrapf(err, "get secret %s", m.CredentialsSecret)
+	}
+	user, pass := string(secret.Data["username"]), string(secret.Data["password"])
+
+	exists, err := db.ChannelExists(ctx, replicator.MigrationChannelName)
+	if err != nil {
+		return errors.Wrap(err, "check migration channel")
+	}
+
+	if m.Cutover {
+		return r.cutoverMigration(ctx, cr, db, primary.Alias, user, pass, exists)
+	}
+
+	// Orchestrator makes the new primary writable after failover
+	superReadOnly, err := db.IsSuperReadonly(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check super_read_only")
+	}
+	if !superReadOnly {
+		if err := db.EnableSuperReadonly(ctx); err != nil {
+			return errors.Wrapf(err, "enable super_read_only on %s", primary.Alias)
+		}
+	}
+
+	if !exists {
+		if err := db.ChangeChannelExternalSource(ctx, replicator.MigrationChannelName, m.Host, user, pass, m.Port); err != nil {
+			return errors.Wrap(err, "configure migration channel")
+		}
+		l.Info("Configured replication from migration source", "primary", primary.Alias, "source", m.Host)
+	}
+
+	rStatus, _, err := db.ChannelReplicationStatus(ctx, replicator.MigrationChannelName)
+	if err != nil {
+		return errors.Wrap(err, "get migration channel status")
+	}
+	if rStatus != replicator.ReplicationStatusActive {
+		if err := db.StartChannelReplication(ctx, replicator.MigrationChannelName); err != nil {
+			return errors.Wrap(err, "start replication from migration source")
+		}
+		l.Info("Started replication from migration source", "primary", primary.Alias)
+	}
+
+	lag, err := db.ChannelReplicationLag(ctx, replicator.MigrationChannelName)
+	if err != nil {
+		return errors.Wrap(err, "get migration lag")
+	}
+
+	status.State = apiv1alpha1.MigrationStateReplicating
+	status.SecondsBehindSource = lag
+
+	return nil
+}
+
+func (r *PerconaServerMySQLReconciler) cutoverMigration(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	primaryDB replicator.Replicator,
+	primary, user, pass string,
+	channelExists bool,
+) error {
+	l := log.FromContext(ctx).WithName("cutoverMigration")
+
+	m := cr.Spec.Migration
+	status := cr.Status.Migration
+	status.State = apiv1alpha1.MigrationStateCuttingOver
+
+	if channelExists {
+		source, err := replicator.NewReplicator(ctx, db.Config{
+			User:     apiv1alpha1.SystemUser(user),
+			Password: pass,
+			Host:     m.Host,
+			Port:     m.Port,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "connect to migration source %s", m.Host)
+		}
+		defer source.Close()
+
+		sourceGTIDs, err := source.GTIDExecuted(ctx)
+		if err != nil {
+			return errors.Wrap(err, "get executed GTIDs of migration source")
+		}
+		gtids, err := primaryDB.GTIDExecuted(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "get executed GTIDs of %s", primary)
+		}
+		applied, err := primaryDB.IsGTIDSubset(ctx, sourceGTIDs, gtids)
+		if err != nil {
+			return errors.Wrap(err, "compare GTIDs with migration source")
+		}
+		if !applied {
+			lag, err := primaryDB.ChannelReplicationLag(ctx, replicator.MigrationChannelName)
+			if err != nil {
+				return errors.Wrap(err, "get migration lag")
+			}
+			status.SecondsBehindSource = lag
+
+			l.Info("Waiting for the primary to apply transactions of migration source", "primary", primary, "lag", lag)
+			return nil
+		}
+
+		if err := primaryDB.ResetChannelReplication(ctx, replicator.MigrationChannelName); err != nil {
+			return errors.Wrap(err, "reset migration channel")
+		}
+	}
+
+	if err := primaryDB.DisableReadonly(ctx); err != nil {
+		return errors.Wrapf(err, "make %s writable", primary)
+	}
+
+	gtids, err := primaryDB.GTIDExecuted(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "get executed GTIDs of %s", primary)
+	}
+
+	status.State = apiv1alpha1.MigrationStateCompleted
+	status.SecondsBehindSource = 0
+	status.GTIDExecuted = gtids
+
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "MigrationCompleted",
+		"%s stopped replication from %s and is writable, executed GTIDs: %s", primary, m.Host, gtids)
+	l.Info("Migration is completed", "primary", primary, "gtidExecuted", gtids)
+
+	return nil
+}
+
+// reconcileHeartbeat creates heartbeat event on the primary if spec.mysql.heartbeat
+// is enabled and drops it otherwise. Event is replicated in disabled state,
+// so it's enabled on a new primary after failover.
+func (r *PerconaServerMySQLReconciler) reconcileHeartbeat(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileHeartbeat")
+
+	if externalReplicationChannel(cr) != "" {
+		// primary is read only, heartbeat is replicated from the source if it has any
+		return nil
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster primary")
+	}
+	primaryHost := getPrimaryHostname(primary, cr)
+
+	operatorPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOperator)
+	if err != nil {
+		return errors.Wrap(err, "get operator password")
+	}
+
+	db, err := newReplicator(ctx, r.Client, cr, apiv1alpha1.UserOperator, operatorPass, primaryHost, mysql.DefaultAdminPort)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %v", primaryHost)
+	}
+	defer db.Close()
+
+	status, err := db.HeartbeatEventStatus(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get heartbeat event status")
+	}
+
+	switch {
+	case !cr.MySQLSpec().Heartbeat && status != "":
+		if err := db.DropHeartbeat(ctx); err != nil {
+			return errors.Wrap(err, "drop heartbeat")
+		}
+		l.Info("Heartbeat event dropped", "primary", primaryHost)
+	case cr.MySQLSpec().Heartbeat && status == "":
+		if err := db.CreateHeartbeat(ctx); err != nil {
+			return errors.Wrap(err, "create heartbeat")
+		}
+		l.Info("Heartbeat event created", "primary", primaryHost)
+	case cr.MySQLSpec().Heartbeat && status != replicator.HeartbeatEnabled:
+		if err := db.EnableHeartbeat(ctx); err != nil {
+			return errors.Wrap(err, "enable heartbeat")
+		}
+		l.Info("Heartbeat event enabled", "primary", primaryHost, "status", status)
+	}
+
+	return nil
+}
+
+// reconcileReplicaPools sets replication delay on the pods of replica pools
+// and recovers data from the pools with spec.mysql.replicaPools[].recovery.
+func (r *PerconaServerMySQLReconciler) reconcileReplicaPools(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileReplicaPools")
+
+	if len(cr.MySQLSpec().ReplicaPools) == 0 {
+		return nil
+	}
+
+	operatorPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOperator)
+	if err != nil {
+		return errors.Wrap(err, "get operator password")
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	for i := range cr.MySQLSpec().ReplicaPools {
+		pool := &cr.MySQLSpec().ReplicaPools[i]
+
+		pods, err := k8s.PodsByLabels(ctx, r.Client, mysql.ReplicaPoolMatchLabels(cr, pool), cr.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "get pods of replica pool %s", pool.Name)
+		}
+
+		if pool.Recovery != nil {
+			// Orchestrator must not repoint or restart replication on recovered instances
+			for _, inst := range instances {
+				if !strings.HasPrefix(inst.Alias, mysql.ReplicaPoolName(cr, pool.Name)+"-") {
+					continue
+				}
+				if err := orchestrator.Forget(ctx, orcAPI, inst.Key.Hostname, inst.Key.Port); err != nil {
+					return errors.Wrapf(err, "forget %s", inst.Alias)
+				}
+				l.Info("Removed recovered instance from Orchestrator", "instance", inst.Alias, "pool", pool.Name)
+			}
+		}
+
+		poolState := apiv1alpha1.RecoveryStatePromoted
+		if len(pods) == 0 {
+			poolState = apiv1alpha1.RecoveryStateApplying
+		}
+		for j := range pods {
+			pod := &pods[j]
+			if pod.Status.PodIP == "" {
+				poolState = apiv1alpha1.RecoveryStateApplying
+				continue
+			}
+
+			state := reconcileReplicaPoolPod(ctx, r.Client, cr, pool, pod, operatorPass)
+			if recoveryStateOrder[state] < recoveryStateOrder[poolState] {
+				poolState = state
+			}
+		}
+
+		if pool.Recovery == nil {
+			continue
+		}
+
+		if cr.Status.ReplicaPools == nil {
+			cr.Status.ReplicaPools = make(map[string]apiv1alpha1.ReplicaPoolStatus)
+		}
+		status := cr.Status.ReplicaPools[pool.Name]
+		if status.Recovery != poolState {
+			l.Info("Replica pool recovery state changed", "pool", pool.Name, "state", poolState)
+			if poolState == apiv1alpha1.RecoveryStateFailed {
+				r.Recorder.Eventf(cr, corev1.EventTypeWarning, "ReplicaPoolRecoveryFailed",
+					"replica pool %s can't be stopped before %s", pool.Name, pool.Recovery.StopBeforeGTIDs)
+			}
+		}
+		status.Recovery = poolState
+		cr.Status.ReplicaPools[pool.Name] = status
+	}
+
+	return nil
+}
+
+// reconcileReplicaPoolPod sets replication delay on the pod of the replica pool
+// or recovers it if the pool has recovery. It returns recovery state of the pod,
+// the state is applying if the pod is not available.
+func reconcileReplicaPoolPod(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	pool *apiv1alpha1.ReplicaPoolSpec,
+	pod *corev1.Pod,
+	operatorPass string,
+) apiv1alpha1.ReplicaPoolRecoveryState {
+	l := log.FromContext(ctx).WithName("reconcileReplicaPoolPod")
+
+	db, err := newPodReplicator(ctx, cl, cr, operatorPass, pod)
+	if err != nil {
+		l.V(1).Info("failed to connect to instance", "instance", pod.Name, "error", err.Error())
+		return apiv1alpha1.RecoveryStateApplying
+	}
+	defer db.Close()
+
+	if pool.Recovery == nil {
+		if err := reconcileReplicationDelay(ctx, db, pool.SourceDelay); err != nil {
+			l.Error(err, "failed to set replication delay", "instance", pod.Name)
+		}
+		return apiv1alpha1.RecoveryStatePromoted
+	}
+
+	state, err := recoverReplicaPoolInstance(ctx, db, pool.Recovery)
+	if err != nil {
+		l.Error(err, "failed to recover instance", "instance", pod.Name)
+		return apiv1alpha1.RecoveryStateApplying
+	}
+
+	return state
+}
+
+// reconcileReplicationDelay sets SOURCE_DELAY on the replica if it's replicating
+func reconcileReplicationDelay(ctx context.Context, db replicator.Replicator, delay int32) error {
+	ioState, sqlState, err := db.ReplicationThreads(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get replication threads state")
+	}
+	if ioState == "" && sqlState == "" {
+		// replication is configured by bootstrap
+		return nil
+	}
+
+	current, err := db.ReplicationDelay(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get replication delay")
+	}
+	if current == delay {
+		return nil
+	}
+
+	return errors.Wrap(db.SetReplicationDelay(ctx, delay), "set replication delay")
+}
+
+// recoveryStateOrder is used to get recovery state of the pool from the states of its pods
+var recoveryStateOrder = map[apiv1alpha1.ReplicaPoolRecoveryState]int{
+	apiv1alpha1.RecoveryStateFailed:   0,
+	apiv1alpha1.RecoveryStateApplying: 1,
+	apiv1alpha1.RecoveryStateStopped:  2,
+	apiv1alpha1.RecoveryStatePromoted: 3,
+}
+
+// recoverReplicaPoolInstance stops replication on the instance of the replica pool
+// before recovery.stopBeforeGTIDs and promotes the instance if it's requested.
+// Persisted skip_slave_start marks that recovery is started.
+func recoverReplicaPoolInstance(ctx context.Context, db replicator.Replicator, recovery *apiv1alpha1.ReplicaPoolRecovery) (apiv1alpha1.ReplicaPoolRecoveryState, error) {
+	ioState, sqlState, err := db.ReplicationThreads(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "get replication threads state")
+	}
+	if ioState == "" && sqlState == "" {
+		readOnly, err := db.IsReadonly(ctx)
+		if err != nil {
+			return "", errors.Wrap(err, "check read only")
+		}
+		if !readOnly {
+			return apiv1alpha1.RecoveryStatePromoted, nil
+		}
+		return apiv1alpha1.RecoveryStateFailed, nil
+	}
+
+	executed, err := db.GTIDExecuted(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "get executed GTIDs")
+	}
+	notApplied, err := db.GTIDSubtract(ctx, recovery.StopBeforeGTIDs, executed)
+	if err != nil {
+		return "", errors.Wrap(err, "subtract executed GTIDs")
+	}
+	ok, err := db.IsGTIDSubset(ctx, recovery.StopBeforeGTIDs, notApplied)
+	if err != nil {
+		return "", errors.Wrap(err, "compare GTIDs")
+	}
+	if !ok {
+		return apiv1alpha1.RecoveryStateFailed, nil
+	}
+
+	started, err := db.IsReplicaStartDisabled(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "check skip_slave_start")
+	}
+	// both threads are stopped if instance was restarted after recovery was started
+	if !started || (ioState != "ON" && sqlState != "ON") {
+		if err := db.DisableReplicaStart(ctx); err != nil {
+			return "", errors.Wrap(err, "disable replica start")
+		}
+		if err := db.StartReplicationUntilBefore(ctx, recovery.StopBeforeGTIDs); err != nil {
+			return "", errors.Wrap(err, "start replication")
+		}
+		return apiv1alpha1.RecoveryStateApplying, nil
+	}
+
+	if sqlState == "ON" {
+		return apiv1alpha1.RecoveryStateApplying, nil
+	}
+
+	if !recovery.Promote {
+		return apiv1alpha1.RecoveryStateStopped, nil
+	}
+
+	if err := db.ResetReplication(ctx); err != nil {
+		return "", errors.Wrap(err, "reset replication")
+	}
+	if err := db.PersistReadonly(ctx, false); err != nil {
+		return "", errors.Wrap(err, "disable read only")
+	}
+
+	return apiv1alpha1.RecoveryStatePromoted, nil
+}
+
+// reconcileReplicaPoolInstances discovers ready pods of replica pools in Orchestrator.
+// orc-discovery registers only pods of the main StatefulSet.
+func (r *PerconaServerMySQLReconciler) reconcileReplicaPoolInstances(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileReplicaPoolInstances")
 
-	cm := &corev1.ConfigMap{}
-	err := r.Client.Get(ctx, orchestrator.NamespacedName(cr), cm)
-	if client.IgnoreNotFound(err) != nil {
-		return errors.Wrap(err, "get config map")
+	if len(cr.MySQLSpec().ReplicaPools) == 0 {
+		return nil
 	}
 
-	existingNodes := make([]string, 0)
-	if !k8serrors.IsNotFound(err) {
-		cfg, ok := cm.Data[orchestrator.ConfigFileName]
-		if !ok {
-			return errors.Errorf("key %s not found in ConfigMap", orchestrator.ConfigFileName)
+	pods, err := k8s.PodsByLabels(ctx, r.Client, mysql.ReplicaPoolsLabels(cr), cr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "get replica pool pods")
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	known := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		known[inst.Alias] = true
+	}
+
+	recovery := make(map[string]bool)
+	for _, pool := range cr.MySQLSpec().ReplicaPools {
+		recovery[pool.Name] = pool.Recovery != nil
+	}
+
+	for i := range pods {
+		pod := pods[i]
+		pool := pod.Labels[apiv1alpha1.MySQLReplicaPoolLabel]
+		if known[pod.Name] || recovery[pool] || !k8s.IsPodReady(pod) {
+			continue
 		}
 
-		config := make(map[string]interface{}, 0)
-		if err := json.Unmarshal([]byte(cfg), &config); err != nil {
-			return errors.Wrap(err, "unmarshal ConfigMap data to json")
+		host := fmt.Sprintf("%s.%s.%s", pod.Name, mysql.ReplicaPoolName(cr, pool), cr.Namespace)
+		if err := orchestrator.Discover(ctx, orcAPI, host, mysql.DefaultPort); err != nil {
+			return errors.Wrapf(err, "discover %s", host)
 		}
 
-		nodes, ok := config["RaftNodes"].([]interface{})
+		l.Info("Discovered replica pool instance", "instance", pod.Name, "pool", pool)
+	}
+
+	return nil
+}
+
+// reconcilePromotionRules registers promotion rule of each MySQL instance in
+// Orchestrator. Registrations expire, so they are renewed on every reconcile.
+func (r *PerconaServerMySQLReconciler) reconcilePromotionRules(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	rules, err := r.promotionRules(ctx, cr, instances)
+	if err != nil {
+		return errors.Wrap(err, "get promotion rules")
+	}
+
+	for _, inst := range instances {
+		rule, ok := rules[inst.Alias]
 		if !ok {
-			return errors.New("key RaftNodes not found in ConfigMap")
+			continue
 		}
+		if err := orchestrator.RegisterCandidate(ctx, orcAPI, inst.Key.Hostname, inst.Key.Port, string(rule)); err != nil {
+			return errors.Wrapf(err, "register candidate %s", inst.Alias)
+		}
+	}
 
-		for _, v := range nodes {
-			existingNodes = append(existingNodes, v.(string))
+	return nil
+}
+
+// promotionRules returns promotion rule of each MySQL instance by its alias.
+// Replica pool instances must not be promoted. Instances whose pods don't
+// exist have no rule.
+func (r *PerconaServerMySQLReconciler) promotionRules(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	instances []*orchestrator.Instance,
+) (map[string]apiv1alpha1.PromotionRuleType, error) {
+	l := log.FromContext(ctx).WithName("promotionRules")
+
+	rules := make(map[string]apiv1alpha1.PromotionRuleType, len(instances))
+	nodeLabels := make(map[string]map[string]string)
+	for _, inst := range instances {
+		if mysql.IsReplicaPoolPod(cr, inst.Alias) {
+			rules[inst.Alias] = apiv1alpha1.PromotionRuleMustNot
+			continue
+		}
+
+		idx, err := ordinal(inst.Alias)
+		if err != nil {
+			continue
+		}
+
+		pod := &corev1.Pod{}
+		if err := r.Get(ctx, types.NamespacedName{Name: inst.Alias, Namespace: cr.Namespace}, pod); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "get pod %s", inst.Alias)
+		}
+
+		rule := apiv1alpha1.PromotionRuleNeutral
+		for _, pr := range cr.MySQLSpec().PromotionRules {
+			if matchesOrdinal(pr.Ordinals, idx) {
+				rule = pr.Rule
+				break
+			}
+
+			if len(pr.NodeSelector) == 0 || pod.Spec.NodeName == "" {
+				continue
+			}
+
+			labels, ok := nodeLabels[pod.Spec.NodeName]
+			if !ok {
+				node := &corev1.Node{}
+				err := r.APIReader.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node)
+				switch {
+				case k8serrors.IsForbidden(err):
+					// namespaced operator without deploy/node-rbac.yaml
+					l.Error(err, "failed to get node, node selector is skipped", "node", pod.Spec.NodeName)
+					r.Recorder.Eventf(cr, corev1.EventTypeWarning, "NodeSelectorIgnored",
+						"Node selectors of promotion rules are ignored, operator is not allowed to get node %s", pod.Spec.NodeName)
+				case k8serrors.IsNotFound(err):
+					l.V(1).Info("Node of the pod is not found", "node", pod.Spec.NodeName, "pod", pod.Name)
+				case err != nil:
+					return nil, errors.Wrapf(err, "get node %s", pod.Spec.NodeName)
+				}
+				labels = node.Labels
+				nodeLabels[pod.Spec.NodeName] = labels
+			}
+
+			if k8slabels.SelectorFromSet(pr.NodeSelector).Matches(k8slabels.Set(labels)) {
+				rule = pr.Rule
+				break
+			}
 		}
+
+		rules[inst.Alias] = rule
 	}
 
-	cmData, err := orchestrator.ConfigMapData(cr)
-	if err != nil {
-		return errors.Wrap(err, "get ConfigMap data")
+	return rules, nil
+}
+
+// promotionRuleRank orders promotion rules from the most preferred candidate
+var promotionRuleRank = map[apiv1alpha1.PromotionRuleType]int{
+	apiv1alpha1.PromotionRulePrefer:    0,
+	apiv1alpha1.PromotionRuleNeutral:   1,
+	apiv1alpha1.PromotionRulePreferNot: 2,
+}
+
+// switchoverCandidate returns the instance with ordinal lower than size
+// the primary should be switched over to. Instances are ordered by their
+// promotion rules, then by ordinal. Instances without a rule or with the
+// must_not rule are never chosen. It returns nil if there is no candidate.
+func switchoverCandidate(
+	instances []*orchestrator.Instance,
+	rules map[string]apiv1alpha1.PromotionRuleType,
+	size int,
+) *orchestrator.Instance {
+	var candidate *orchestrator.Instance
+	candidateRank, candidateIdx := 0, 0
+	for _, inst := range instances {
+		rank, ok := promotionRuleRank[rules[inst.Alias]]
+		if !ok {
+			continue
+		}
+		idx, err := ordinal(inst.Alias)
+		if err != nil || idx >= size {
+			continue
+		}
+		if candidate != nil && (rank > candidateRank || rank == candidateRank && idx > candidateIdx) {
+			continue
+		}
+		candidate, candidateRank, candidateIdx = inst, rank, idx
 	}
 
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, orchestrator.ConfigMap(cr, cmData), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile ConfigMap")
+	return candidate
+}
+
+func matchesOrdinal(ordinals []int, idx int) bool {
+	for _, o := range ordinals {
+		if o == idx {
+			return true
+		}
 	}
+	return false
+}
 
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, orchestrator.StatefulSet(cr), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile StatefulSet")
+// reconcileFullClusterCrash recovers the cluster after all MySQL pods were
+// restarted at once. In this case all instances are read only and none of them
+// is replicating. The instance which has a superset of GTIDs executed on all
+// other instances is elected as the primary. If instances have diverged, the
+// instance with the most complete GTID set is elected only if
+// spec.mysql.forceUnsafeBootstrap is enabled. Instances with transactions
+// missing on the primary are cloned from it in background.
+func (r *PerconaServerMySQLReconciler) reconcileFullClusterCrash(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileFullClusterCrash")
+
+	pods, err := k8s.PodsByLabels(ctx, r.Client, mysql.MatchLabels(cr), cr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "get MySQL pod list")
 	}
 
-	raftNodes := orchestrator.RaftNodes(cr)
-	if len(existingNodes) == 0 || len(existingNodes) == len(raftNodes) {
+	if len(pods) == 0 || len(pods) < int(cr.MySQLSpec().Size) {
 		return nil
 	}
 
-	orcHost := orchestrator.APIHost(cr)
-	g, gCtx := errgroup.WithContext(context.Background())
+	operatorPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOperator)
+	if err != nil {
+		return errors.Wrap(err, "get operator password")
+	}
 
-	if len(raftNodes) > len(existingNodes) {
-		newPeers := util.Difference(raftNodes, existingNodes)
+	sort.Slice(pods, func(i, j int) bool {
+		oi, _ := ordinal(pods[i].Name)
+		oj, _ := ordinal(pods[j].Name)
+		return oi < oj
+	})
 
-		for _, peer := range newPeers {
-			p := peer
-			g.Go(func() error {
-				return orchestrator.AddPeer(gCtx, orcHost, p)
-			})
+	dbs := make([]replicator.Replicator, 0, len(pods))
+	defer func() {
+		for _, db := range dbs {
+			db.Close()
 		}
+	}()
 
-		l.Error(g.Wait(), "Orchestrator add peers", "peers", newPeers)
-	} else {
-		oldPeers := util.Difference(existingNodes, raftNodes)
+	gtids := make([]string, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.PodIP == "" {
+			return nil
+		}
 
-		for _, peer := range oldPeers {
-			p := peer
-			g.Go(func() error {
-				return orchestrator.RemovePeer(gCtx, orcHost, p)
-			})
+		db, err := newPodReplicator(ctx, r.Client, cr, operatorPass, pod)
+		if err != nil {
+			// not all instances are up
+			return nil
 		}
+		dbs = append(dbs, db)
 
-		l.Error(g.Wait(), "Orchestrator remove peers", "peers", oldPeers)
+		if isReplica, err := db.IsReplica(ctx); err != nil {
+			return errors.Wrapf(err, "check if %s is replica", pod.Name)
+		} else if isReplica {
+			return nil
+		}
+
+		if channel := externalReplicationChannel(cr); channel != "" {
+			status, _, err := db.ChannelReplicationStatus(ctx, channel)
+			if err != nil {
+				return errors.Wrapf(err, "check if %s replicates from %s channel", pod.Name, channel)
+			}
+			if status == replicator.ReplicationStatusActive {
+				return nil
+			}
+		}
+
+		if readOnly, err := db.IsReadonly(ctx); err != nil {
+			return errors.Wrapf(err, "check if %s is read only", pod.Name)
+		} else if !readOnly {
+			return nil
+		}
+
+		gtid, err := db.GTIDExecuted(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "get executed GTIDs of %s", pod.Name)
+		}
+		gtids = append(gtids, gtid)
 	}
 
-	return nil
-}
+	l.Info("Full cluster crash detected, all instances are read only and not replicating")
 
-func (r *PerconaServerMySQLReconciler) reconcileOrchestratorServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
-	if err := k8s.EnsureObjectWithHash(ctx, r.Client, cr, orchestrator.Service(cr), r.Scheme); err != nil {
-		return errors.Wrap(err, "reconcile Service")
+	subset := func(a, b string) (bool, error) {
+		return dbs[0].IsGTIDSubset(ctx, a, b)
+	}
+	election, err := electPrimary(gtids, cr.MySQLSpec().ForceUnsafeBootstrap, subset)
+	if err != nil {
+		return errors.Wrap(err, "elect primary")
 	}
+	primary := election.primary
 
-	exposer := orchestrator.Exposer(*cr)
-	if err := r.reconcileServicePerPod(ctx, cr, &exposer); err != nil {
-		return errors.Wrap(err, "reconcile service per pod")
+	if election.diverged {
+		if !election.elected {
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, "FullClusterCrashDiverged",
+				"Instances have diverged, none of them has all transactions. "+
+					"Set spec.mysql.forceUnsafeBootstrap to elect %s as the primary", pods[primary].Name)
+			l.Info("Instances have diverged, waiting for forceUnsafeBootstrap", "candidate", pods[primary].Name)
+			return nil
+		}
+
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "FullClusterCrashUnsafeBootstrap",
+			"Instances have diverged, %s is elected as the primary because forceUnsafeBootstrap is enabled", pods[primary].Name)
 	}
 
-	return nil
-}
+	primaryIdx, err := ordinal(pods[primary].Name)
+	if err != nil {
+		return errors.Wrapf(err, "get ordinal of %s", pods[primary].Name)
+	}
+	primaryHost := mysql.FQDN(cr, primaryIdx)
 
-func (r *PerconaServerMySQLReconciler) reconcileServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
-	if err := r.reconcileMySQLServices(ctx, cr); err != nil {
-		return errors.Wrap(err, "reconcile MySQL services")
+	// primary stays read only while it replicates from outside of the cluster,
+	// see reconcileReplicationSource and reconcileMigration
+	if externalReplicationChannel(cr) == "" {
+		if err := dbs[primary].DisableReadonly(ctx); err != nil {
+			return errors.Wrapf(err, "make %s writable", pods[primary].Name)
+		}
 	}
 
-	if err := r.reconcileOrchestratorServices(ctx, cr); err != nil {
-		return errors.Wrap(err, "reconcile Orchestrator services")
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "FullClusterCrashRecovered",
+		"%s is elected as the primary after full cluster crash, executed GTIDs: %s", pods[primary].Name, gtids[primary])
+	l.Info("Elected primary after full cluster crash", "primary", pods[primary].Name, "gtidExecuted", gtids[primary])
+
+	replicaPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserReplication)
+	if err != nil {
+		return errors.Wrap(err, "get replication password")
+	}
+
+	for _, i := range election.reclone {
+		// instance has transactions which don't exist on the primary, it needs to be re-cloned
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "FullClusterCrashReclone",
+			"%s has diverged from the primary %s and will be cloned from it", pods[i].Name, pods[primary].Name)
+		l.Info("Cloning diverged instance", "instance", pods[i].Name, "primary", pods[primary].Name)
+
+		r.startClone(ctx, cr, operatorPass, &pods[i], primaryHost)
+	}
+
+	for _, i := range election.rejoin {
+		if err := dbs[i].StopReplication(ctx); err != nil {
+			return errors.Wrapf(err, "stop replication on %s", pods[i].Name)
+		}
+
+		if err := dbs[i].StartReplication(ctx, primaryHost, replicaPass, mysql.DefaultPort); err != nil {
+			return errors.Wrapf(err, "start replication on %s", pods[i].Name)
+		}
+
+		l.Info("Instance rejoined as replica", "instance", pods[i].Name, "primary", pods[primary].Name)
 	}
 
 	return nil
 }
 
-func (r *PerconaServerMySQLReconciler) reconcileReplication(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
-	l := log.FromContext(ctx).WithName("reconcileReplication")
+// primaryElection is the result of primary election after full cluster crash
+type primaryElection struct {
+	// primary is the index of the instance with GTIDs of the most other instances
+	primary int
+	// diverged is true if the primary doesn't have GTIDs of all other instances
+	diverged bool
+	// elected is false if instances diverged and unsafe bootstrap isn't forced
+	elected bool
+	// rejoin are indexes of instances which replicate from the primary as is
+	rejoin []int
+	// reclone are indexes of instances with transactions missing on the primary
+	reclone []int
+}
 
-	sts := &appsv1.StatefulSet{}
-	if err := r.Get(ctx, client.ObjectKeyFromObject(orchestrator.StatefulSet(cr)), sts); err != nil {
-		return client.IgnoreNotFound(err)
-	}
+// electPrimary elects the instance whose GTID set is a superset of GTID sets of
+// all other instances. If there is no such instance, the instance which has
+// GTIDs of the most other instances is elected only if forceUnsafeBootstrap is
+// set. Ties are resolved in favor of the lower index. subset returns true if
+// GTID set a is a subset of GTID set b.
+func electPrimary(gtids []string, forceUnsafeBootstrap bool, subset func(a, b string) (bool, error)) (primaryElection, error) {
+	// subsets[i][j] is true if GTIDs of instance j are a subset of GTIDs of instance i
+	subsets := make([][]bool, len(gtids))
+	primary, primaryScore := -1, -1
+	for i := range gtids {
+		subsets[i] = make([]bool, len(gtids))
+		score := 0
+		for j := range gtids {
+			if i == j {
+				subsets[i][j] = true
+				continue
+			}
 
-	if sts.Status.ReadyReplicas == 0 {
-		l.Info("orchestrator is not ready. skip", "ready", sts.Status.ReadyReplicas)
-		return nil
+			ok, err := subset(gtids[j], gtids[i])
+			if err != nil {
+				return primaryElection{}, errors.Wrapf(err, "compare GTIDs of instances %d and %d", j, i)
+			}
+			subsets[i][j] = ok
+			if ok {
+				score++
+			}
+		}
+
+		if score > primaryScore {
+			primary, primaryScore = i, score
+		}
 	}
 
-	if err := reconcileReplicationPrimaryPod(ctx, r.Client, cr); err != nil {
-		return errors.Wrap(err, "reconcile primary pod")
+	election := primaryElection{primary: primary}
+	election.diverged = primaryScore < len(gtids)-1
+	election.elected = !election.diverged || forceUnsafeBootstrap
+	if !election.elected {
+		return election, nil
 	}
-	if err := reconcileReplicationSemiSync(ctx, r.Client, cr); err != nil {
-		return errors.Wrap(err, "reconcile semi-sync")
+
+	for i := range gtids {
+		switch {
+		case i == primary:
+		case subsets[primary][i]:
+			election.rejoin = append(election.rejoin, i)
+		default:
+			election.reclone = append(election.reclone, i)
+		}
 	}
 
-	return nil
+	return election, nil
 }
 
+// failoverNoticeTTL is the period when the primary from the last failover notice
+// is used if Orchestrator API is not available
+const failoverNoticeTTL = time.Minute
+
 func reconcileReplicationPrimaryPod(
 	ctx context.Context,
 	cl client.Client,
@@ -720,59 +2548,287 @@ func reconcileReplicationPrimaryPod(
 ) error {
 	l := log.FromContext(ctx).WithName("reconcileReplicationPrimaryPod")
 
-	pods, err := k8s.PodsByLabels(ctx, cl, mysql.MatchLabels(cr))
+	pods, err := k8s.PodsByLabels(ctx, cl, mysql.MatchLabels(cr), cr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "get MySQL pod list")
+	}
+	l.V(1).Info(fmt.Sprintf("got %v pods", len(pods)))
+
+	var primaryAlias string
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, cl, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		notice, ok := LastFailoverNotice(cr)
+		if !ok || !notice.IsSuccessful || time.Since(notice.ReceivedAt) > failoverNoticeTTL {
+			return errors.Wrap(err, "get cluster primary")
+		}
+
+		primaryAlias = hostAlias(notice.SuccessorHost)
+		l.Info("Orchestrator API is not available, using primary from the last failover notice",
+			"primary", primaryAlias, "error", err.Error())
+	} else {
+		primaryAlias = primary.Alias
+		l.V(1).Info(fmt.Sprintf("got cluster primary alias: %v", primaryAlias), "data", primary)
+	}
+
+	metrics.SetPrimary(cr, primaryAlias)
+
+	for i := range pods {
+		pod := pods[i].DeepCopy()
+		if pod.GetLabels()[apiv1alpha1.MySQLPrimaryLabel] == "true" {
+			if pod.Name == primaryAlias {
+				l.Info(fmt.Sprintf("primary %v is not changed. skip", primaryAlias))
+				return nil
+			}
+
+			k8s.RemoveLabel(pod, apiv1alpha1.MySQLPrimaryLabel)
+			if err := cl.Patch(ctx, pod, client.StrategicMergeFrom(&pods[i])); err != nil {
+				return errors.Wrapf(err, "remove label from old primary pod: %v/%v",
+					pod.GetNamespace(), pod.GetName())
+			}
+			metrics.IncFailovers(cr)
+
+			l.Info(fmt.Sprintf("removed label from old primary pod: %v/%v",
+				pod.GetNamespace(), pod.GetName()))
+			break
+		}
+	}
+
+	for i := range pods {
+		pod := pods[i].DeepCopy()
+		if pod.Name == primaryAlias {
+			k8s.AddLabel(pod, apiv1alpha1.MySQLPrimaryLabel, "true")
+			if err := cl.Patch(ctx, pod, client.StrategicMergeFrom(&pods[i])); err != nil {
+				return errors.Wrapf(err, "add label to new primary pod %v/%v",
+					pod.GetNamespace(), pod.GetName())
+			}
+
+			l.Info(fmt.Sprintf("added label to new primary pod: %v/%v",
+				pod.GetNamespace(), pod.GetName()))
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// checkSplitBrainReplica switches the replica to super_read_only if it's
+// writable. It returns whether the replica was writable and its executed GTIDs.
+func checkSplitBrainReplica(ctx context.Context, db replicator.Replicator, instance, primary string) (bool, string, error) {
+	l := log.FromContext(ctx).WithName("checkSplitBrainReplica")
+
+	readOnly, err := db.IsReadonly(ctx)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "check if %s is read only", instance)
+	}
+	superReadOnly, err := db.IsSuperReadonly(ctx)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "check if %s is super read only", instance)
+	}
+
+	if !readOnly {
+		if err := db.EnableSuperReadonly(ctx); err != nil {
+			return true, "", errors.Wrapf(err, "enable super_read_only on %s", instance)
+		}
+		l.Info("Writable replica is switched to super_read_only", "instance", instance, "primary", primary)
+	} else if !superReadOnly {
+		l.V(1).Info("replica is not super_read_only", "instance", instance)
+	}
+
+	gtid, err := db.GTIDExecuted(ctx)
+	if err != nil {
+		return !readOnly, "", errors.Wrapf(err, "get executed GTIDs of %s", instance)
+	}
+
+	return !readOnly, gtid, nil
+}
+
+// reconcileSplitBrain checks that the primary is the only writable instance
+// and that replicas don't have transactions which don't exist on the primary.
+// Writable replicas are switched to super_read_only. Both writable replicas and
+// replicas with errant transactions are labeled as fenced to fail readiness
+// checks and to be removed from services.
+func (r *PerconaServerMySQLReconciler) reconcileSplitBrain(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("reconcileSplitBrain")
+
+	if ready, err := r.isOrchestratorReady(ctx, cr); err != nil || !ready {
+		return err
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster primary")
+	}
+
+	pods, err := k8s.PodsByLabels(ctx, r.Client, mysql.MatchLabels(cr), cr.Namespace)
 	if err != nil {
 		return errors.Wrap(err, "get MySQL pod list")
 	}
-	l.V(1).Info(fmt.Sprintf("got %v pods", len(pods)))
 
-	host := orchestrator.APIHost(cr)
-	primary, err := orchestrator.ClusterPrimary(ctx, host, cr.ClusterHint())
+	operatorPass, err := k8s.UserPassword(ctx, r.Client, cr, apiv1alpha1.UserOperator)
 	if err != nil {
-		return errors.Wrap(err, "get cluster primary")
+		return errors.Wrap(err, "get operator password")
 	}
-	primaryAlias := primary.Alias
-	l.V(1).Info(fmt.Sprintf("got cluster primary alias: %v", primaryAlias), "data", primary)
 
+	var primaryPod *corev1.Pod
+	writable := make(map[string]bool)
+	gtids := make(map[string]string)
 	for i := range pods {
-		pod := pods[i].DeepCopy()
-		if pod.GetLabels()[apiv1alpha1.MySQLPrimaryLabel] == "true" {
-			if pod.Name == primaryAlias {
-				l.Info(fmt.Sprintf("primary %v is not changed. skip", primaryAlias))
-				return nil
-			}
+		pod := &pods[i]
+		if pod.Name == primary.Alias {
+			primaryPod = pod
+			continue
+		}
+		if pod.Status.PodIP == "" {
+			continue
+		}
 
-			k8s.RemoveLabel(pod, apiv1alpha1.MySQLPrimaryLabel)
-			if err := cl.Patch(ctx, pod, client.StrategicMergeFrom(&pods[i])); err != nil {
-				return errors.Wrapf(err, "remove label from old primary pod: %v/%v",
-					pod.GetNamespace(), pod.GetName())
-			}
+		db, err := newPodReplicator(ctx, r.Client, cr, operatorPass, pod)
+		if err != nil {
+			l.V(1).Info("failed to connect to instance", "instance", pod.Name, "error", err.Error())
+			continue
+		}
 
-			l.Info(fmt.Sprintf("removed label from old primary pod: %v/%v",
-				pod.GetNamespace(), pod.GetName()))
-			break
+		wasWritable, gtid, err := checkSplitBrainReplica(ctx, db, pod.Name, primary.Alias)
+		db.Close()
+		if err != nil {
+			return err
+		}
+		writable[pod.Name] = wasWritable
+		gtids[pod.Name] = gtid
+	}
+
+	// GTIDs of the primary are read after GTIDs of the replicas
+	// to not treat transactions committed in between as errant
+	errant := make(map[string]string)
+	if primaryPod != nil && primaryPod.Status.PodIP != "" && len(gtids) > 0 {
+		db, err := newPodReplicator(ctx, r.Client, cr, operatorPass, primaryPod)
+		if err != nil {
+			return errors.Wrapf(err, "connect to primary %s", primaryPod.Name)
+		}
+		defer db.Close()
+
+		primaryGTID, err := db.GTIDExecuted(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "get executed GTIDs of primary %s", primaryPod.Name)
+		}
+
+		for name, gtid := range gtids {
+			diff, err := db.GTIDSubtract(ctx, gtid, primaryGTID)
+			if err != nil {
+				return errors.Wrapf(err, "compare GTIDs of %s and primary", name)
+			}
+			if diff != "" {
+				errant[name] = diff
+			}
 		}
 	}
 
 	for i := range pods {
 		pod := pods[i].DeepCopy()
-		if pod.Name == primaryAlias {
-			k8s.AddLabel(pod, apiv1alpha1.MySQLPrimaryLabel, "true")
-			if err := cl.Patch(ctx, pod, client.StrategicMergeFrom(&pods[i])); err != nil {
-				return errors.Wrapf(err, "add label to new primary pod %v/%v",
-					pod.GetNamespace(), pod.GetName())
+		_, hasErrant := errant[pod.Name]
+		fence := writable[pod.Name] || hasErrant
+		fenced := pod.GetLabels()[apiv1alpha1.MySQLFencedLabel] == "true"
+
+		switch {
+		case fence && !fenced:
+			k8s.AddLabel(pod, apiv1alpha1.MySQLFencedLabel, "true")
+			if writable[pod.Name] {
+				r.Recorder.Eventf(cr, corev1.EventTypeWarning, "SplitBrain",
+					"%s is writable but the primary is %s, instance is fenced", pod.Name, primary.Alias)
+			}
+			if hasErrant {
+				r.Recorder.Eventf(cr, corev1.EventTypeWarning, "ErrantTransactions",
+					"%s has transactions which don't exist on the primary %s: %s, instance is fenced",
+					pod.Name, primary.Alias, errant[pod.Name])
 			}
+			l.Info("Fencing instance", "instance", pod.Name, "writable", writable[pod.Name], "errantGTIDs", errant[pod.Name])
+		case !fence && fenced && pod.Name != primary.Alias:
+			if _, ok := gtids[pod.Name]; !ok {
+				// instance wasn't checked
+				continue
+			}
+			k8s.RemoveLabel(pod, apiv1alpha1.MySQLFencedLabel)
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, "Unfenced", "%s is read only and consistent with the primary", pod.Name)
+			l.Info("Unfencing instance", "instance", pod.Name)
+		case fenced && pod.Name == primary.Alias:
+			// instance was promoted by Orchestrator
+			k8s.RemoveLabel(pod, apiv1alpha1.MySQLFencedLabel)
+			l.Info("Unfencing primary", "instance", pod.Name)
+		default:
+			continue
+		}
 
-			l.Info(fmt.Sprintf("added label to new primary pod: %v/%v",
-				pod.GetNamespace(), pod.GetName()))
+		if err := r.Client.Patch(ctx, pod, client.StrategicMergeFrom(&pods[i])); err != nil {
+			return errors.Wrapf(err, "patch labels of pod %s", pod.Name)
+		}
+	}
 
-			break
+	splitBrain := metav1.Condition{
+		Type:    apiv1alpha1.ConditionSplitBrain,
+		Status:  metav1.ConditionFalse,
+		Reason:  "SingleWritable",
+		Message: fmt.Sprintf("%s is the only writable instance", primary.Alias),
+	}
+	if len(writable) > 0 {
+		splitBrain.Status = metav1.ConditionTrue
+		splitBrain.Reason = "WritableReplicas"
+		splitBrain.Message = fmt.Sprintf("writable replicas are fenced: %s", strings.Join(sortedKeys(writable), ", "))
+	}
+	meta.SetStatusCondition(&cr.Status.Conditions, splitBrain)
+
+	errantCondition := metav1.Condition{
+		Type:    apiv1alpha1.ConditionErrantTransactions,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoErrantTransactions",
+		Message: "replicas are consistent with the primary",
+	}
+	if len(errant) > 0 {
+		instances := make(map[string]bool, len(errant))
+		for name := range errant {
+			instances[name] = true
 		}
+		errantCondition.Status = metav1.ConditionTrue
+		errantCondition.Reason = "ErrantTransactions"
+		errantCondition.Message = fmt.Sprintf("replicas have errant transactions and are fenced: %s",
+			strings.Join(sortedKeys(instances), ", "))
 	}
+	meta.SetStatusCondition(&cr.Status.Conditions, errantCondition)
 
 	return nil
 }
 
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *PerconaServerMySQLReconciler) isOrchestratorReady(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) (bool, error) {
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, orchestrator.NamespacedName(cr), sts); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return sts.Status.ReadyReplicas > 0, nil
+}
+
+// reconcileReplicationSemiSync enables semi-sync source on the primary and
+// semi-sync replica on other instances if spec.mysql.sizeSemiSync is not zero.
+// Settings are enforced on every reconcile, so a new primary is configured after failover.
 func reconcileReplicationSemiSync(
 	ctx context.Context,
 	cl client.Reader,
@@ -780,8 +2836,11 @@ func reconcileReplicationSemiSync(
 ) error {
 	l := log.FromContext(ctx).WithName("reconcileReplicationSemiSync")
 
-	host := orchestrator.APIHost(cr)
-	primary, err := orchestrator.ClusterPrimary(ctx, host, cr.ClusterHint())
+	orcAPI, err := orchestrator.ClusterAPI(ctx, cl, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cr.ClusterHint())
 	if err != nil {
 		return errors.Wrap(err, "get cluster primary")
 	}
@@ -795,7 +2854,10 @@ func reconcileReplicationSemiSync(
 		return errors.Wrap(err, "get operator password")
 	}
 
-	db, err := replicator.NewReplicator(apiv1alpha1.UserOperator,
+	semiSyncSize := cr.MySQLSpec().SizeSemiSync.IntValue()
+	enabled := semiSyncSize > 0
+
+	db, err := newReplicator(ctx, cl, cr, apiv1alpha1.UserOperator,
 		operatorPass,
 		primaryHost,
 		mysql.DefaultAdminPort)
@@ -804,20 +2866,107 @@ func reconcileReplicationSemiSync(
 	}
 	defer db.Close()
 
-	if err := db.SetSemiSyncSource(cr.MySQLSpec().SizeSemiSync.IntValue() > 0); err != nil {
+	if err := db.SetSemiSyncSource(ctx, enabled); err != nil {
 		return errors.Wrapf(err, "set semi-sync source on %#v", primaryHost)
 	}
-	l.Info(fmt.Sprintf("set semi-sync source on %v", primaryHost))
+	l.V(1).Info(fmt.Sprintf("set semi-sync source on %v", primaryHost))
 
-	if err := db.SetSemiSyncSize(cr.MySQLSpec().SizeSemiSync.IntValue()); err != nil {
+	if err := db.SetSemiSyncSize(ctx, semiSyncSize); err != nil {
 		return errors.Wrapf(err, "set semi-sync size on %v", primaryHost)
 	}
-	l.Info(fmt.Sprintf("set semi-sync size on %v", primaryHost))
+	l.V(1).Info(fmt.Sprintf("set semi-sync size on %v", primaryHost))
+
+	if err := db.SetSemiSyncWaitPoint(ctx, string(cr.MySQLSpec().SemiSyncType)); err != nil {
+		return errors.Wrapf(err, "set semi-sync wait point on %v", primaryHost)
+	}
+
+	if err := db.SetSemiSyncReplica(ctx, false); err != nil {
+		return errors.Wrapf(err, "disable semi-sync replica on %v", primaryHost)
+	}
+
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	replicas := make([]string, 0, len(instances))
+	replicaEnabled := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		if inst.Key == primary.Key {
+			continue
+		}
+
+		replicas = append(replicas, inst.Key.Hostname)
+		// replica pools don't acknowledge semi-sync transactions
+		replicaEnabled[inst.Key.Hostname] = enabled && !mysql.IsReplicaPoolPod(cr, inst.Alias)
+	}
+
+	results := parallel.Run(ctx, parallel.DefaultLimit, replicas, func(ctx context.Context, host string) error {
+		return reconcileSemiSyncReplica(ctx, cl, cr, operatorPass, host, replicaEnabled[host])
+	})
+	// unavailable replicas are configured when they are back
+	for _, host := range results.Failed() {
+		l.Error(results[host], "failed to reconcile semi-sync replica", "host", host)
+	}
+
+	clients, err := db.SemiSyncClients(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "get semi-sync clients of %v", primaryHost)
+	}
+	cr.Status.SemiSyncReplicas = int32(clients)
+
+	return nil
+}
+
+// reconcileSemiSyncReplica sets rpl_semi_sync_slave_enabled on the replica.
+// IO thread is restarted to apply the change if replica is replicating.
+func reconcileSemiSyncReplica(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	operatorPass, host string,
+	enabled bool,
+) error {
+	l := log.FromContext(ctx).WithName("reconcileSemiSyncReplica")
+
+	db, err := newReplicator(ctx, cl, cr, apiv1alpha1.UserOperator, operatorPass, host, mysql.DefaultAdminPort)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %v", host)
+	}
+	defer db.Close()
+
+	if err := db.SetSemiSyncSource(ctx, false); err != nil {
+		return errors.Wrap(err, "disable semi-sync source")
+	}
+
+	current, err := db.IsSemiSyncReplica(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check semi-sync replica")
+	}
+	if current == enabled {
+		return nil
+	}
+
+	if err := db.SetSemiSyncReplica(ctx, enabled); err != nil {
+		return errors.Wrap(err, "set semi-sync replica")
+	}
+
+	status, _, err := db.ReplicationStatus(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get replication status")
+	}
+	if status == replicator.ReplicationStatusActive {
+		if err := db.RestartReplicationIOThread(ctx); err != nil {
+			return errors.Wrap(err, "restart IO thread")
+		}
+	}
+
+	l.Info("Semi-sync replica changed", "host", host, "enabled", enabled)
 
 	return nil
 }
 
-func (r *PerconaServerMySQLReconciler) cleanupOutdatedServices(ctx context.Context, exposer Exposer) error {
+func (r *PerconaServerMySQLReconciler) cleanupOutdatedServices(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL, exposer Exposer) error {
 	l := log.FromContext(ctx).WithName("cleanupOutdatedServices")
 
 	size := int(exposer.Size())
@@ -836,7 +2985,7 @@ func (r *PerconaServerMySQLReconciler) cleanupOutdatedServices(ctx context.Conte
 
 	svcLabels := exposer.Labels()
 	svcLabels[apiv1alpha1.ExposedLabel] = "true"
-	services, err := k8s.ServicesByLabels(ctx, r.Client, svcLabels)
+	services, err := k8s.ServicesByLabels(ctx, r.Client, svcLabels, cr.Namespace)
 	if err != nil {
 		return errors.Wrap(err, "get exposed services")
 	}
@@ -857,15 +3006,154 @@ func (r *PerconaServerMySQLReconciler) cleanupOutdatedServices(ctx context.Conte
 
 func (r *PerconaServerMySQLReconciler) cleanupOutdated(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
 	mysqlExposer := mysql.Exposer(*cr)
-	if err := r.cleanupOutdatedServices(ctx, &mysqlExposer); err != nil {
+	if err := r.cleanupOutdatedServices(ctx, cr, &mysqlExposer); err != nil {
 		return errors.Wrap(err, "cleanup MySQL services")
 	}
 
 	orcExposer := orchestrator.Exposer(*cr)
-	if err := r.cleanupOutdatedServices(ctx, &orcExposer); err != nil {
+	if err := r.cleanupOutdatedServices(ctx, cr, &orcExposer); err != nil {
 		return errors.Wrap(err, "cleanup Orchestrator services")
 	}
 
+	if err := r.cleanupOutdatedReplicaPools(ctx, cr); err != nil {
+		return errors.Wrap(err, "cleanup replica pools")
+	}
+
+	if err := r.cleanupOutdatedInstances(ctx, cr); err != nil {
+		return errors.Wrap(err, "cleanup MySQL instances")
+	}
+
+	if cr.MySQLSpec().DeletePVCOnScaleDown {
+		if err := r.cleanupOutdatedPVCs(ctx, cr); err != nil {
+			return errors.Wrap(err, "cleanup MySQL PVCs")
+		}
+	}
+
+	return nil
+}
+
+// cleanupOutdatedReplicaPools deletes StatefulSets and Services of replica pools removed from spec.mysql.replicaPools
+func (r *PerconaServerMySQLReconciler) cleanupOutdatedReplicaPools(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("cleanupOutdatedReplicaPools")
+
+	pools := make(map[string]bool, len(cr.MySQLSpec().ReplicaPools))
+	for _, pool := range cr.MySQLSpec().ReplicaPools {
+		pools[pool.Name] = true
+	}
+
+	stsList, err := k8s.StatefulSetsByLabels(ctx, r.Client, mysql.ReplicaPoolsLabels(cr), cr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "get replica pool StatefulSets")
+	}
+	for i := range stsList {
+		sts := &stsList[i]
+		if pools[sts.Labels[apiv1alpha1.MySQLReplicaPoolLabel]] {
+			continue
+		}
+
+		l.Info("Deleting StatefulSet of removed replica pool", "sts", sts.Name)
+		if err := r.Client.Delete(ctx, sts); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete StatefulSet/%s", sts.Name)
+		}
+	}
+
+	services, err := k8s.ServicesByLabels(ctx, r.Client, mysql.ReplicaPoolsLabels(cr), cr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "get replica pool services")
+	}
+	for i := range services {
+		svc := &services[i]
+		if pools[svc.Labels[apiv1alpha1.MySQLReplicaPoolLabel]] {
+			continue
+		}
+
+		l.Info("Deleting service of removed replica pool", "service", svc.Name)
+		if err := r.Client.Delete(ctx, svc); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete Service/%s", svc.Name)
+		}
+	}
+
+	return nil
+}
+
+// cleanupOutdatedInstances forgets instances of the pods removed by scale down
+// and the pods of removed replica pools in Orchestrator
+func (r *PerconaServerMySQLReconciler) cleanupOutdatedInstances(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("cleanupOutdatedInstances")
+
+	if ready, err := r.isOrchestratorReady(ctx, cr); err != nil || !ready {
+		return err
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, r.Client, cr)
+	if err != nil {
+		return errors.Wrap(err, "get Orchestrator API client")
+	}
+	instances, err := orchestrator.ClusterInstances(ctx, orcAPI, cr.ClusterHint())
+	if err != nil {
+		return errors.Wrap(err, "get cluster instances")
+	}
+
+	for _, inst := range instances {
+		if !mysql.IsReplicaPoolPod(cr, inst.Alias) {
+			i, err := ordinal(inst.Alias)
+			if err != nil || i < int(cr.MySQLSpec().Size) {
+				continue
+			}
+		}
+
+		nn := types.NamespacedName{Name: inst.Alias, Namespace: cr.Namespace}
+		exists, err := k8s.ObjectExists(ctx, r.Client, nn, &corev1.Pod{})
+		if err != nil {
+			return errors.Wrapf(err, "check if pod %s exists", inst.Alias)
+		}
+		if exists {
+			continue
+		}
+
+		if err := orchestrator.Forget(ctx, orcAPI, inst.Key.Hostname, inst.Key.Port); err != nil {
+			return errors.Wrapf(err, "forget %s", inst.Alias)
+		}
+
+		l.Info("Removed instance from Orchestrator", "instance", inst.Alias)
+	}
+
+	return nil
+}
+
+// cleanupOutdatedPVCs deletes PVCs of the pods removed by scale down
+func (r *PerconaServerMySQLReconciler) cleanupOutdatedPVCs(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) error {
+	l := log.FromContext(ctx).WithName("cleanupOutdatedPVCs")
+
+	pvcs, err := k8s.PVCsByLabels(ctx, r.Client, mysql.MatchLabels(cr), cr.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "get PVC list")
+	}
+
+	for i := range pvcs {
+		pvc := &pvcs[i]
+
+		idx, err := ordinal(pvc.Name)
+		if err != nil || idx < int(cr.MySQLSpec().Size) {
+			continue
+		}
+
+		nn := types.NamespacedName{Name: mysql.PodName(cr, idx), Namespace: cr.Namespace}
+		exists, err := k8s.ObjectExists(ctx, r.Client, nn, &corev1.Pod{})
+		if err != nil {
+			return errors.Wrapf(err, "check if pod %s exists", nn.Name)
+		}
+		if exists {
+			continue
+		}
+
+		if err := r.Client.Delete(ctx, pvc); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete PVC/%s", pvc.Name)
+		}
+
+		l.Info("Deleted PVC of removed pod", "pvc", pvc.Name)
+	}
+
 	return nil
 }
 
@@ -875,24 +3163,53 @@ func (r *PerconaServerMySQLReconciler) reconcileCRStatus(
 ) error {
 	l := log.FromContext(ctx).WithName("reconcileCRStatus")
 
-	mysqlStatus, err := appStatus(ctx, r.Client, cr.MySQLSpec().Size, mysql.MatchLabels(cr))
+	mysqlStatus, err := appStatus(ctx, r.Client, cr.MySQLSpec().Size, mysql.MatchLabels(cr), cr.Namespace)
 	if err != nil {
 		return errors.Wrap(err, "get MySQL status")
 	}
 	cr.Status.MySQL = mysqlStatus
 
-	orcStatus, err := appStatus(ctx, r.Client, cr.OrchestratorSpec().Size, orchestrator.MatchLabels(cr))
+	orcStatus, err := appStatus(ctx, r.Client, cr.OrchestratorSpec().Size, orchestrator.MatchLabels(cr), cr.Namespace)
 	if err != nil {
 		return errors.Wrap(err, "get Orchestrator status")
 	}
 	cr.Status.Orchestrator = orcStatus
 
-	if cr.Status.MySQL.State == cr.Status.Orchestrator.State {
+	if cr.Spec.Migration == nil {
+		cr.Status.Migration = nil
+	}
+
+	poolsReady := true
+	poolStatuses := cr.Status.ReplicaPools
+	cr.Status.ReplicaPools = nil
+	for i := range cr.MySQLSpec().ReplicaPools {
+		pool := &cr.MySQLSpec().ReplicaPools[i]
+		appStatus, err := appStatus(ctx, r.Client, pool.Size, mysql.ReplicaPoolMatchLabels(cr, pool), cr.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "get status of replica pool %s", pool.Name)
+		}
+
+		if cr.Status.ReplicaPools == nil {
+			cr.Status.ReplicaPools = make(map[string]apiv1alpha1.ReplicaPoolStatus)
+		}
+		// recovery state is set by reconcileReplicaPools
+		poolStatus := poolStatuses[pool.Name]
+		poolStatus.StatefulAppStatus = appStatus
+		if pool.Recovery == nil {
+			poolStatus.Recovery = ""
+		}
+		cr.Status.ReplicaPools[pool.Name] = poolStatus
+		poolsReady = poolsReady && appStatus.State == apiv1alpha1.StateReady
+	}
+
+	if cr.Status.MySQL.State == cr.Status.Orchestrator.State && poolsReady {
 		cr.Status.State = cr.Status.MySQL.State
 	} else {
 		cr.Status.State = apiv1alpha1.StateInitializing
 	}
 
+	metrics.SetClusterState(cr)
+
 	l.V(1).Info("Writing CR status", "state", cr.Status.State, "orchestrator", cr.Status.Orchestrator, "mysql", cr.Status.MySQL)
 
 	nn := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
@@ -904,13 +3221,14 @@ func appStatus(
 	cl client.Reader,
 	size int32,
 	labels map[string]string,
+	namespace string,
 ) (apiv1alpha1.StatefulAppStatus, error) {
 	status := apiv1alpha1.StatefulAppStatus{
 		Size:  size,
 		State: apiv1alpha1.StateInitializing,
 	}
 
-	pods, err := k8s.PodsByLabels(ctx, cl, labels)
+	pods, err := k8s.PodsByLabels(ctx, cl, labels, namespace)
 	if err != nil {
 		return status, errors.Wrap(err, "get pod list")
 	}
@@ -949,6 +3267,155 @@ func writeStatus(
 	})
 }
 
+// cloneTimeout limits clone of a diverged instance after full cluster crash
+const cloneTimeout = 6 * time.Hour
+
+// startClone clones the instance of the pod from the donor in background, so
+// reconcile isn't blocked until all data is copied. It does nothing if clone
+// of the instance is already running.
+func (r *PerconaServerMySQLReconciler) startClone(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	operatorPass string,
+	pod *corev1.Pod,
+	donor string,
+) {
+	l := log.FromContext(ctx).WithName("startClone").WithValues("instance", pod.Name, "donor", donor)
+
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}.String()
+	if _, running := r.clones.LoadOrStore(key, struct{}{}); running {
+		l.V(1).Info("Clone is already in progress")
+		return
+	}
+
+	cr, pod = cr.DeepCopy(), pod.DeepCopy()
+	go func() {
+		defer r.clones.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cloneTimeout)
+		defer cancel()
+
+		if err := r.cloneInstance(ctx, cr, operatorPass, pod, donor); err != nil {
+			l.Error(err, "clone finished with error")
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, "CloneFailed",
+				"Clone of %s from %s failed: %s", pod.Name, donor, err.Error())
+			return
+		}
+
+		l.Info("Clone finished")
+	}()
+}
+
+// cloneInstance clones the instance of the pod from the donor. The instance
+// restarts after clone is finished and bootstrap configures replication.
+func (r *PerconaServerMySQLReconciler) cloneInstance(
+	ctx context.Context,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	operatorPass string,
+	pod *corev1.Pod,
+	donor string,
+) error {
+	cfg, err := podDBConfig(ctx, r.Client, cr, operatorPass, pod)
+	if err != nil {
+		return err
+	}
+	// CLONE INSTANCE doesn't return until all data is copied
+	cfg.ReadTimeout = db.NoTimeout
+
+	recipient, err := replicator.NewReplicator(ctx, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %s", pod.Name)
+	}
+	defer recipient.Close()
+
+	return recipient.Clone(ctx, donor, string(apiv1alpha1.UserOperator), operatorPass, mysql.DefaultAdminPort)
+}
+
+// dbConfig returns config of the connection to the MySQL instance of the
+// cluster, the certificate of the instance is verified with the cluster CA
+func dbConfig(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	user apiv1alpha1.SystemUser,
+	pass, host string,
+	port int32,
+) (db.Config, error) {
+	ca, err := clusterCA(ctx, cl, cr)
+	if err != nil {
+		return db.Config{}, errors.Wrap(err, "get cluster CA")
+	}
+
+	return db.Config{User: user, Password: pass, Host: host, Port: port, CA: ca}, nil
+}
+
+// podDBConfig returns config of the operator connection to the admin port of
+// the MySQL pod by its IP. Instances may not have DNS records yet, e.g. while
+// they are not ready, so the certificate is verified against the host name of
+// the pod in its governing service instead.
+func podDBConfig(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	operatorPass string,
+	pod *corev1.Pod,
+) (db.Config, error) {
+	cfg, err := dbConfig(ctx, cl, cr, apiv1alpha1.UserOperator, operatorPass, pod.Status.PodIP, mysql.DefaultAdminPort)
+	if err != nil {
+		return db.Config{}, err
+	}
+	cfg.ServerName = fmt.Sprintf("%s.%s.%s", pod.Name, pod.Spec.Subdomain, pod.Namespace)
+
+	return cfg, nil
+}
+
+func newPodReplicator(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	operatorPass string,
+	pod *corev1.Pod,
+) (replicator.Replicator, error) {
+	cfg, err := podDBConfig(ctx, cl, cr, operatorPass, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	return replicator.NewReplicator(ctx, cfg)
+}
+
+func newReplicator(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	user apiv1alpha1.SystemUser,
+	pass, host string,
+	port int32,
+) (replicator.Replicator, error) {
+	cfg, err := dbConfig(ctx, cl, cr, user, pass, host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return replicator.NewReplicator(ctx, cfg)
+}
+
+func newUserManager(
+	ctx context.Context,
+	cl client.Reader,
+	cr *apiv1alpha1.PerconaServerMySQL,
+	user apiv1alpha1.SystemUser,
+	pass, host string,
+	port int32,
+) (users.Manager, error) {
+	cfg, err := dbConfig(ctx, cl, cr, user, pass, host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return users.NewManager(ctx, cfg)
+}
+
 func getPrimaryHostname(primary *orchestrator.Instance, cr *apiv1alpha1.PerconaServerMySQL) string {
 	if primary.Key.Hostname != "" {
 		return primary.Key.Hostname
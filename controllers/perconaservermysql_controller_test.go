@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/orchestrator"
+)
+
+func TestSwitchoverCandidate(t *testing.T) {
+	instances := []*orchestrator.Instance{
+		{Alias: "cluster1-mysql-0"},
+		{Alias: "cluster1-mysql-1"},
+		{Alias: "cluster1-mysql-2"},
+		{Alias: "cluster1-mysql-delayed-0"},
+	}
+
+	tests := []struct {
+		name     string
+		rules    map[string]apiv1alpha1.PromotionRuleType
+		size     int
+		expected string
+	}{
+		{
+			name: "lowest ordinal of neutral instances",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-0": apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-1": apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-2": apiv1alpha1.PromotionRuleNeutral,
+			},
+			size:     2,
+			expected: "cluster1-mysql-0",
+		},
+		{
+			name: "prefer wins over lower ordinal",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-0": apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-1": apiv1alpha1.PromotionRulePrefer,
+				"cluster1-mysql-2": apiv1alpha1.PromotionRuleNeutral,
+			},
+			size:     3,
+			expected: "cluster1-mysql-1",
+		},
+		{
+			name: "must_not is skipped",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-0": apiv1alpha1.PromotionRuleMustNot,
+				"cluster1-mysql-1": apiv1alpha1.PromotionRulePreferNot,
+				"cluster1-mysql-2": apiv1alpha1.PromotionRuleNeutral,
+			},
+			size:     2,
+			expected: "cluster1-mysql-1",
+		},
+		{
+			name: "prefer_not after neutral",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-0": apiv1alpha1.PromotionRulePreferNot,
+				"cluster1-mysql-1": apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-2": apiv1alpha1.PromotionRulePrefer,
+			},
+			size:     2,
+			expected: "cluster1-mysql-1",
+		},
+		{
+			name: "removed instances aren't candidates",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-0": apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-1": apiv1alpha1.PromotionRulePrefer,
+				"cluster1-mysql-2": apiv1alpha1.PromotionRulePrefer,
+			},
+			size:     1,
+			expected: "cluster1-mysql-0",
+		},
+		{
+			name: "instances without rule and replica pools are skipped",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-1":         apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-delayed-0": apiv1alpha1.PromotionRuleMustNot,
+			},
+			size:     2,
+			expected: "cluster1-mysql-1",
+		},
+		{
+			name: "no candidate",
+			rules: map[string]apiv1alpha1.PromotionRuleType{
+				"cluster1-mysql-0":         apiv1alpha1.PromotionRuleMustNot,
+				"cluster1-mysql-1":         apiv1alpha1.PromotionRuleNeutral,
+				"cluster1-mysql-delayed-0": apiv1alpha1.PromotionRuleMustNot,
+			},
+			size:     1,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if candidate := switchoverCandidate(instances, tt.rules, tt.size); candidate != nil {
+				got = candidate.Alias
+			}
+			if got != tt.expected {
+				t.Errorf("expected candidate %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// gtidSubset treats GTID sets as comma separated transactions
+func gtidSubset(a, b string) (bool, error) {
+	if strings.Contains(a, "invalid") || strings.Contains(b, "invalid") {
+		return false, errors.New("malformed GTID set")
+	}
+
+	executed := make(map[string]bool)
+	for _, trx := range strings.Split(b, ",") {
+		executed[trx] = true
+	}
+	for _, trx := range strings.Split(a, ",") {
+		if trx != "" && !executed[trx] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func TestElectPrimary(t *testing.T) {
+	tests := []struct {
+		name                 string
+		gtids                []string
+		forceUnsafeBootstrap bool
+		expected             primaryElection
+		expectedErr          bool
+	}{
+		{
+			name:     "superset on the last instance",
+			gtids:    []string{"a1", "a1,a2", "a1,a2,a3"},
+			expected: primaryElection{primary: 2, elected: true, rejoin: []int{0, 1}},
+		},
+		{
+			name:     "equal GTIDs elect the lowest index",
+			gtids:    []string{"a1,a2", "a1,a2", "a1,a2"},
+			expected: primaryElection{primary: 0, elected: true, rejoin: []int{1, 2}},
+		},
+		{
+			name:     "empty GTIDs",
+			gtids:    []string{"", "", "a1"},
+			expected: primaryElection{primary: 2, elected: true, rejoin: []int{0, 1}},
+		},
+		{
+			name:     "single instance",
+			gtids:    []string{"a1"},
+			expected: primaryElection{primary: 0, elected: true},
+		},
+		{
+			name:     "diverged instances wait for forceUnsafeBootstrap",
+			gtids:    []string{"a1,a2", "a1,a2,a3", "a1,a2,b3"},
+			expected: primaryElection{primary: 1, diverged: true},
+		},
+		{
+			name:                 "diverged instance is recloned with forceUnsafeBootstrap",
+			gtids:                []string{"a1,a2", "a1,a2,a3", "a1,a2,b3"},
+			forceUnsafeBootstrap: true,
+			expected:             primaryElection{primary: 1, diverged: true, elected: true, rejoin: []int{0}, reclone: []int{2}},
+		},
+		{
+			name:                 "forceUnsafeBootstrap doesn't affect safe election",
+			gtids:                []string{"a1", "a1,a2"},
+			forceUnsafeBootstrap: true,
+			expected:             primaryElection{primary: 1, elected: true, rejoin: []int{0}},
+		},
+		{
+			name:        "comparison error",
+			gtids:       []string{"a1", "invalid"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			election, err := electPrimary(tt.gtids, tt.forceUnsafeBootstrap, gtidSubset)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(election, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, election)
+			}
+		})
+	}
+}
+
+func TestRotationDue(t *testing.T) {
+	last := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spec     apiv1alpha1.PasswordRotationSpec
+		now      time.Time
+		expected bool
+	}{
+		{
+			name:     "interval hasn't passed",
+			spec:     apiv1alpha1.PasswordRotationSpec{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			now:      last.Add(23 * time.Hour),
+			expected: false,
+		},
+		{
+			name:     "interval has passed",
+			spec:     apiv1alpha1.PasswordRotationSpec{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			now:      last.Add(24 * time.Hour),
+			expected: true,
+		},
+		{
+			name:     "before scheduled time",
+			spec:     apiv1alpha1.PasswordRotationSpec{Schedule: "0 3 * * sun"},
+			now:      time.Date(2024, 1, 21, 2, 59, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "at scheduled time",
+			spec:     apiv1alpha1.PasswordRotationSpec{Schedule: "0 3 * * sun"},
+			now:      time.Date(2024, 1, 21, 3, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "scheduled time was missed",
+			spec:     apiv1alpha1.PasswordRotationSpec{Schedule: "0 3 * * sun"},
+			now:      time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due, err := rotationDue(&tt.spec, last, tt.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if due != tt.expected {
+				t.Errorf("expected due %t, got %t", tt.expected, due)
+			}
+		})
+	}
+
+	if _, err := rotationDue(&apiv1alpha1.PasswordRotationSpec{Schedule: "invalid"}, last, last); err == nil {
+		t.Error("expected error for invalid schedule")
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sretry "k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/platform"
+)
+
+// databaseFinalizer drops the database when PerconaServerMySQLDatabase with drop deletion policy is deleted
+const databaseFinalizer = "delete-mysql-database"
+
+// PerconaServerMySQLDatabaseReconciler reconciles a PerconaServerMySQLDatabase object
+type PerconaServerMySQLDatabaseReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	ServerVersion *platform.ServerVersion
+}
+
+//+kubebuilder:rbac:groups=ps.percona.com,resources=perconaservermysqldatabases;perconaservermysqldatabases/status;perconaservermysqldatabases/finalizers,verbs=get;list;watch;create;update;patch;delete
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PerconaServerMySQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.PerconaServerMySQLDatabase{}).
+		Complete(r)
+}
+
+// Reconcile creates the database on the primary of the referenced cluster and
+// makes its default character set and collation match the spec.
+func (r *PerconaServerMySQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx).WithName("PerconaServerMySQLDatabase")
+
+	rr := ctrl.Result{RequeueAfter: 30 * time.Second}
+
+	db := &apiv1alpha1.PerconaServerMySQLDatabase{}
+	if err := r.Client.Get(ctx, req.NamespacedName, db); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return rr, errors.Wrapf(err, "get %v", req.NamespacedName.String())
+	}
+
+	if !db.DeletionTimestamp.IsZero() {
+		if err := r.deleteDatabase(ctx, db); err != nil {
+			return rr, errors.Wrap(err, "delete database")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// finalizer is needed only to drop the database
+	dropOnDelete := db.Spec.DeletionPolicy == apiv1alpha1.DatabaseDeletionPolicyDrop
+	if dropOnDelete != controllerutil.ContainsFinalizer(db, databaseFinalizer) {
+		if dropOnDelete {
+			controllerutil.AddFinalizer(db, databaseFinalizer)
+		} else {
+			controllerutil.RemoveFinalizer(db, databaseFinalizer)
+		}
+
+		if err := r.Client.Update(ctx, db); err != nil {
+			return rr, errors.Wrap(err, "update finalizers")
+		}
+	}
+
+	defer func() {
+		if err := writeDatabaseStatus(ctx, r.Client, req.NamespacedName, db.Status); err != nil {
+			l.Error(err, "failed to update status")
+		}
+	}()
+
+	if err := db.CheckNSetDefaults(); err != nil {
+		db.Status.State = apiv1alpha1.DatabaseStateError
+		db.Status.Message = err.Error()
+		return ctrl.Result{}, nil
+	}
+
+	err := r.reconcileDatabase(ctx, db)
+	switch {
+	case errors.Is(err, errClusterNotReady):
+		db.Status.State = apiv1alpha1.DatabaseStatePending
+		db.Status.Message = err.Error()
+		return rr, nil
+	case err != nil:
+		db.Status.State = apiv1alpha1.DatabaseStateError
+		db.Status.Message = err.Error()
+		return rr, errors.Wrap(err, "reconcile database")
+	}
+
+	db.Status.State = apiv1alpha1.DatabaseStateReady
+	db.Status.Message = ""
+
+	return rr, nil
+}
+
+func (r *PerconaServerMySQLDatabaseReconciler) reconcileDatabase(ctx context.Context, db *apiv1alpha1.PerconaServerMySQLDatabase) error {
+	cluster, err := readyCluster(ctx, r.Client, r.ServerVersion, db.Namespace, db.Spec.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	um, err := primaryUserManager(ctx, r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer um.Close()
+
+	if err := um.EnsureDatabase(ctx, db.Spec.Name, db.Spec.Charset, db.Spec.Collation); err != nil {
+		return err
+	}
+	db.Status.Name = db.Spec.Name
+
+	return nil
+}
+
+// deleteDatabase drops the database and removes the finalizer. The database
+// isn't dropped if the cluster is deleted.
+func (r *PerconaServerMySQLDatabaseReconciler) deleteDatabase(ctx context.Context, db *apiv1alpha1.PerconaServerMySQLDatabase) error {
+	l := log.FromContext(ctx).WithName("deleteDatabase")
+
+	if !controllerutil.ContainsFinalizer(db, databaseFinalizer) {
+		return nil
+	}
+
+	if db.Status.Name != "" {
+		cluster, err := readyCluster(ctx, r.Client, r.ServerVersion, db.Namespace, db.Spec.ClusterName)
+		switch {
+		case k8serrors.IsNotFound(errors.Cause(err)):
+			l.Info("Cluster is not found, skipping drop of database", "cluster", db.Spec.ClusterName, "database", db.Status.Name)
+		case err != nil:
+			return err
+		default:
+			um, err := primaryUserManager(ctx, r.Client, cluster)
+			if err != nil {
+				return err
+			}
+			defer um.Close()
+
+			if err := um.DropDatabase(ctx, db.Status.Name); err != nil {
+				return err
+			}
+			l.Info("Dropped database", "database", db.Status.Name)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(db, databaseFinalizer)
+	return errors.Wrap(r.Client.Update(ctx, db), "remove finalizer")
+}
+
+func writeDatabaseStatus(
+	ctx context.Context,
+	cl client.Client,
+	nn types.NamespacedName,
+	status apiv1alpha1.PerconaServerMySQLDatabaseStatus,
+) error {
+	return k8sretry.RetryOnConflict(k8sretry.DefaultRetry, func() error {
+		db := &apiv1alpha1.PerconaServerMySQLDatabase{}
+		if err := cl.Get(ctx, nn, db); err != nil {
+			return errors.Wrapf(err, "get %v", nn.String())
+		}
+
+		db.Status = status
+		if err := cl.Status().Update(ctx, db); err != nil {
+			return errors.Wrapf(err, "update %v", nn.String())
+		}
+
+		return nil
+	})
+}
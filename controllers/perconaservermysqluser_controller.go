@@ -0,0 +1,413 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	k8sretry "k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/k8s"
+	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
+	"github.com/percona/percona-server-mysql-operator/pkg/orchestrator"
+	"github.com/percona/percona-server-mysql-operator/pkg/platform"
+	"github.com/percona/percona-server-mysql-operator/pkg/users"
+)
+
+// userFinalizer drops the MySQL user when PerconaServerMySQLUser is deleted
+const userFinalizer = "delete-mysql-user"
+
+// errClusterNotReady is returned when the referenced cluster can't apply changes yet
+var errClusterNotReady = errors.New("cluster is not ready")
+
+// PerconaServerMySQLUserReconciler reconciles a PerconaServerMySQLUser object
+type PerconaServerMySQLUserReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	ServerVersion *platform.ServerVersion
+	Recorder      record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=ps.percona.com,resources=perconaservermysqlusers;perconaservermysqlusers/status;perconaservermysqlusers/finalizers,verbs=get;list;watch;create;update;patch;delete
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PerconaServerMySQLUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.PerconaServerMySQLUser{}).
+		Complete(r)
+}
+
+// Reconcile creates the MySQL user on the primary of the referenced cluster and
+// makes its hosts, password, resource limits and grants match the spec.
+// The user is dropped when PerconaServerMySQLUser is deleted.
+func (r *PerconaServerMySQLUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx).WithName("PerconaServerMySQLUser")
+
+	rr := ctrl.Result{RequeueAfter: 30 * time.Second}
+
+	user := &apiv1alpha1.PerconaServerMySQLUser{}
+	if err := r.Client.Get(ctx, req.NamespacedName, user); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return rr, errors.Wrapf(err, "get %v", req.NamespacedName.String())
+	}
+
+	if !user.DeletionTimestamp.IsZero() {
+		if err := r.deleteUser(ctx, user); err != nil {
+			return rr, errors.Wrap(err, "delete user")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(user, userFinalizer) {
+		controllerutil.AddFinalizer(user, userFinalizer)
+		if err := r.Client.Update(ctx, user); err != nil {
+			return rr, errors.Wrap(err, "add finalizer")
+		}
+	}
+
+	defer func() {
+		if err := writeUserStatus(ctx, r.Client, req.NamespacedName, user.Status); err != nil {
+			l.Error(err, "failed to update status")
+		}
+	}()
+
+	if err := user.CheckNSetDefaults(); err != nil {
+		user.Status.State = apiv1alpha1.UserStateError
+		user.Status.Message = err.Error()
+		return ctrl.Result{}, nil
+	}
+
+	err := r.reconcileUser(ctx, user)
+	switch {
+	case errors.Is(err, errClusterNotReady):
+		user.Status.State = apiv1alpha1.UserStatePending
+		user.Status.Message = err.Error()
+		return rr, nil
+	case err != nil:
+		user.Status.State = apiv1alpha1.UserStateError
+		user.Status.Message = err.Error()
+		return rr, errors.Wrap(err, "reconcile user")
+	}
+
+	user.Status.State = apiv1alpha1.UserStateReady
+	user.Status.Message = ""
+
+	return rr, nil
+}
+
+func (r *PerconaServerMySQLUserReconciler) reconcileUser(ctx context.Context, user *apiv1alpha1.PerconaServerMySQLUser) error {
+	l := log.FromContext(ctx).WithName("reconcileUser")
+
+	cluster, err := readyCluster(ctx, r.Client, r.ServerVersion, user.Namespace, user.Spec.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	passSecret := &corev1.Secret{}
+	nn := types.NamespacedName{Name: user.Spec.PasswordSecretRef.Name, Namespace: user.Namespace}
+	if err := r.Client.Get(ctx, nn, passSecret); err != nil {
+		return errors.Wrapf(err, "get Secret/%s", nn.Name)
+	}
+	pass, ok := passSecret.Data[user.Spec.PasswordSecretRef.Key]
+	if !ok {
+		return errors.Errorf("Secret/%s has no %s key", nn.Name, user.Spec.PasswordSecretRef.Key)
+	}
+
+	um, err := primaryUserManager(ctx, r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer um.Close()
+
+	status := &user.Status
+	mysqlUser := func(hosts []string) mysql.User {
+		return mysql.User{
+			Username: apiv1alpha1.SystemUser(user.Spec.Name),
+			Password: string(pass),
+			Hosts:    hosts,
+		}
+	}
+
+	desiredHosts := sets.NewString(user.Spec.Hosts...)
+	currentHosts := sets.NewString(status.Hosts...)
+
+	if removed := currentHosts.Difference(desiredHosts); removed.Len() > 0 {
+		if err := um.DropUser(ctx, mysqlUser(removed.List())); err != nil {
+			return errors.Wrap(err, "drop user from removed hosts")
+		}
+		l.Info("Dropped user from removed hosts", "user", user.Spec.Name, "hosts", removed.List())
+	}
+
+	// old password is discarded in the reconcile after the retention period
+	// to give applications time to switch to the new password
+	if status.PasswordChanged != nil {
+		retain := time.Duration(0)
+		if user.Spec.RetainOldPassword != nil {
+			retain = user.Spec.RetainOldPassword.Duration
+		}
+		if time.Since(status.PasswordChanged.Time) >= retain {
+			if err := um.DiscardOldPasswords(ctx, []mysql.User{mysqlUser(currentHosts.Intersection(desiredHosts).List())}); err != nil {
+				return errors.Wrap(err, "discard old password")
+			}
+			status.PasswordChanged = nil
+			l.Info("Discarded old password", "user", user.Spec.Name)
+		}
+	}
+
+	hash := userPasswordHash(user, pass)
+	if status.PasswordHash == "" {
+		currentHosts = sets.NewString()
+	}
+
+	existingHosts := currentHosts.Intersection(desiredHosts)
+	if status.PasswordHash != "" && status.PasswordHash != hash && existingHosts.Len() > 0 {
+		if err := um.UpdateUserPasswords(ctx, []mysql.User{mysqlUser(existingHosts.List())}); err != nil {
+			return errors.Wrap(err, "update password")
+		}
+		now := metav1.Now()
+		status.PasswordChanged = &now
+		r.Recorder.Eventf(user, corev1.EventTypeNormal, "PasswordChanged", "password of %s is changed", user.Spec.Name)
+	}
+
+	if added := desiredHosts.Difference(currentHosts); added.Len() > 0 {
+		if err := um.EnsureUser(ctx, mysqlUser(added.List())); err != nil {
+			return errors.Wrap(err, "create user")
+		}
+		l.Info("Created user", "user", user.Spec.Name, "hosts", added.List())
+	}
+	status.Hosts = desiredHosts.List()
+	status.PasswordHash = hash
+
+	if err := um.SetResourceLimits(ctx, mysqlUser(status.Hosts), user.Spec.Limits); err != nil {
+		return errors.Wrap(err, "set resource limits")
+	}
+
+	for _, db := range user.Spec.Databases {
+		if err := um.EnsureDatabase(ctx, db, "", ""); err != nil {
+			return errors.Wrap(err, "ensure database")
+		}
+	}
+
+	for _, applied := range status.Grants {
+		if grantInList(applied, user.Spec.Grants) {
+			continue
+		}
+		if err := um.Revoke(ctx, mysqlUser(status.Hosts), applied); err != nil {
+			return errors.Wrap(err, "revoke removed grant")
+		}
+	}
+	for _, grant := range user.Spec.Grants {
+		if err := um.Grant(ctx, mysqlUser(status.Hosts), grant); err != nil {
+			return errors.Wrap(err, "grant privileges")
+		}
+	}
+	status.Grants = user.Spec.Grants
+
+	if err := r.reconcileUserBinding(ctx, cluster, user, string(pass)); err != nil {
+		return errors.Wrap(err, "reconcile binding secret")
+	}
+
+	return nil
+}
+
+// reconcileUserBinding writes connection details of the user to the binding Secret
+func (r *PerconaServerMySQLUserReconciler) reconcileUserBinding(
+	ctx context.Context,
+	cluster *apiv1alpha1.PerconaServerMySQL,
+	user *apiv1alpha1.PerconaServerMySQLUser,
+	pass string,
+) error {
+	status := &user.Status
+
+	if !user.Spec.Binding.Enabled {
+		if err := deleteBindingSecret(ctx, r.Client, user.Namespace, status.Binding); err != nil {
+			return err
+		}
+		status.Binding = nil
+		return nil
+	}
+
+	if status.Binding != nil && status.Binding.Name != user.Spec.Binding.SecretName {
+		if err := deleteBindingSecret(ctx, r.Client, user.Namespace, status.Binding); err != nil {
+			return err
+		}
+	}
+
+	ca, err := clusterCA(ctx, r.Client, cluster)
+	if err != nil {
+		return err
+	}
+
+	database := ""
+	if len(user.Spec.Databases) > 0 {
+		database = user.Spec.Databases[0]
+	}
+
+	binding := mysql.BindingSecret(cluster, user.Spec.Binding.SecretName, user.Spec.Name, pass, database, ca)
+	if err := k8s.EnsureObjectWithHash(ctx, r.Client, user, binding, r.Scheme); err != nil {
+		return errors.Wrapf(err, "ensure Secret/%s", binding.Name)
+	}
+	status.Binding = &corev1.LocalObjectReference{Name: binding.Name}
+
+	return nil
+}
+
+// deleteUser drops the MySQL user and removes the finalizer. The user isn't
+// dropped if the cluster is deleted.
+func (r *PerconaServerMySQLUserReconciler) deleteUser(ctx context.Context, user *apiv1alpha1.PerconaServerMySQLUser) error {
+	l := log.FromContext(ctx).WithName("deleteUser")
+
+	if !controllerutil.ContainsFinalizer(user, userFinalizer) {
+		return nil
+	}
+
+	if len(user.Status.Hosts) > 0 {
+		name := user.Spec.Name
+		if name == "" {
+			name = user.Name
+		}
+
+		cluster, err := readyCluster(ctx, r.Client, r.ServerVersion, user.Namespace, user.Spec.ClusterName)
+		switch {
+		case k8serrors.IsNotFound(errors.Cause(err)):
+			l.Info("Cluster is not found, skipping drop of user", "cluster", user.Spec.ClusterName, "user", name)
+		case err != nil:
+			return err
+		default:
+			um, err := primaryUserManager(ctx, r.Client, cluster)
+			if err != nil {
+				return err
+			}
+			defer um.Close()
+
+			if err := um.DropUser(ctx, mysql.User{Username: apiv1alpha1.SystemUser(name), Hosts: user.Status.Hosts}); err != nil {
+				return errors.Wrap(err, "drop user")
+			}
+			l.Info("Dropped user", "user", name)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(user, userFinalizer)
+	return errors.Wrap(r.Client.Update(ctx, user), "remove finalizer")
+}
+
+// readyCluster returns the cluster with defaults if its MySQL is ready.
+// Not found error is returned as is, so it can be checked by the caller.
+func readyCluster(
+	ctx context.Context,
+	cl client.Reader,
+	serverVersion *platform.ServerVersion,
+	namespace, name string,
+) (*apiv1alpha1.PerconaServerMySQL, error) {
+	cluster := &apiv1alpha1.PerconaServerMySQL{}
+	if err := cl.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, cluster); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, errors.Wrapf(err, "get cluster %s", name)
+	}
+	if err := cluster.CheckNSetDefaults(serverVersion); err != nil {
+		return nil, errors.Wrapf(err, "check and set defaults for cluster %s", name)
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() || cluster.Status.MySQL.State != apiv1alpha1.StateReady {
+		return nil, errors.Wrapf(errClusterNotReady, "cluster %s", name)
+	}
+
+	return cluster, nil
+}
+
+// primaryUserManager connects users.Manager to the current primary of the cluster
+func primaryUserManager(ctx context.Context, cl client.Client, cluster *apiv1alpha1.PerconaServerMySQL) (users.Manager, error) {
+	operatorPass, err := k8s.UserPassword(ctx, cl, cluster, apiv1alpha1.UserOperator)
+	if err != nil {
+		return nil, errors.Wrap(err, "get operator password")
+	}
+
+	orcAPI, err := orchestrator.ClusterAPI(ctx, cl, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "get Orchestrator API client")
+	}
+	primary, err := orchestrator.ClusterPrimary(ctx, orcAPI, cluster.ClusterHint())
+	if err != nil {
+		return nil, errors.Wrap(err, "get cluster primary")
+	}
+
+	um, err := newUserManager(ctx, cl, cluster, apiv1alpha1.UserOperator, operatorPass, getPrimaryHostname(primary, cluster), mysql.DefaultAdminPort)
+	if err != nil {
+		return nil, errors.Wrap(err, "init user manager")
+	}
+
+	return um, nil
+}
+
+// userPasswordHash is salted with UID of the user, so equal passwords
+// of different users have different hashes
+func userPasswordHash(user *apiv1alpha1.PerconaServerMySQLUser, pass []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(append([]byte(user.UID), pass...)))
+}
+
+func grantInList(grant apiv1alpha1.UserGrant, grants []apiv1alpha1.UserGrant) bool {
+	for i := range grants {
+		if reflect.DeepEqual(grant, grants[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeUserStatus(
+	ctx context.Context,
+	cl client.Client,
+	nn types.NamespacedName,
+	status apiv1alpha1.PerconaServerMySQLUserStatus,
+) error {
+	return k8sretry.RetryOnConflict(k8sretry.DefaultRetry, func() error {
+		user := &apiv1alpha1.PerconaServerMySQLUser{}
+		if err := cl.Get(ctx, nn, user); err != nil {
+			return errors.Wrapf(err, "get %v", nn.String())
+		}
+
+		user.Status = status
+		if err := cl.Status().Update(ctx, user); err != nil {
+			return errors.Wrapf(err, "update %v", nn.String())
+		}
+
+		return nil
+	})
+}
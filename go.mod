@@ -7,6 +7,7 @@ require (
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.15.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.0
 	github.com/sjmudd/stopwatch v0.0.0-20170613150411-f380bf8a9be1
 	k8s.io/api v0.22.4
 	k8s.io/apimachinery v0.22.4
@@ -14,7 +15,11 @@ require (
 	sigs.k8s.io/controller-runtime v0.10.3
 )
 
-require golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+require (
+	github.com/go-logr/logr v0.4.0
+	github.com/prometheus/client_model v0.2.0
+	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+)
 
 require (
 	cloud.google.com/go v0.54.0 // indirect
@@ -30,7 +35,6 @@ require (
 	github.com/evanphx/json-patch v4.11.0+incompatible // indirect
 	github.com/form3tech-oss/jwt-go v3.2.3+incompatible // indirect
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
-	github.com/go-logr/logr v0.4.0 // indirect
 	github.com/go-logr/zapr v0.4.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
@@ -45,8 +49,6 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.1 // indirect
 	github.com/nxadm/tail v1.4.8 // indirect
-	github.com/prometheus/client_golang v1.11.0 // indirect
-	github.com/prometheus/client_model v0.2.0 // indirect
 	github.com/prometheus/common v0.26.0 // indirect
 	github.com/prometheus/procfs v0.6.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+const (
+	DefaultDialTimeout  = 10 * time.Second
+	DefaultReadTimeout  = 30 * time.Second
+	DefaultWriteTimeout = 30 * time.Second
+
+	// NoTimeout disables the read or write timeout, e.g. for CLONE INSTANCE
+	// which doesn't return until all data is copied
+	NoTimeout time.Duration = -1
+)
+
+// Config of the connection to a MySQL instance
+type Config struct {
+	User     apiv1alpha1.SystemUser
+	Password string
+	Host     string
+	Port     int32
+
+	// CA verifies the certificate of the server. If CA is empty, TLS is used
+	// if the server supports it, but the certificate is not verified.
+	CA []byte
+	// ServerName is the host name the certificate of the server is verified
+	// against. Host is used if it's empty, so it's required if Host is an IP.
+	ServerName string
+
+	// Timeouts are the default ones if they are zero
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// Open connects to the instance and pings it
+func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
+	mcfg, err := driverConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := mysqldriver.NewConnector(mcfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "connect to MySQL")
+	}
+	db := sql.OpenDB(connector)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "ping database")
+	}
+
+	return db, nil
+}
+
+// driverConfig returns config of the MySQL driver. TLS config is registered
+// in the driver if CA is set.
+func driverConfig(cfg Config) (*mysqldriver.Config, error) {
+	mcfg := mysqldriver.NewConfig()
+	mcfg.User = string(cfg.User)
+	mcfg.Passwd = cfg.Password
+	mcfg.Net = "tcp"
+	mcfg.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port)))
+	mcfg.DBName = "performance_schema"
+	mcfg.InterpolateParams = true
+	mcfg.Timeout = timeout(cfg.DialTimeout, DefaultDialTimeout)
+	mcfg.ReadTimeout = timeout(cfg.ReadTimeout, DefaultReadTimeout)
+	mcfg.WriteTimeout = timeout(cfg.WriteTimeout, DefaultWriteTimeout)
+
+	mcfg.TLSConfig = "preferred"
+	if len(cfg.CA) > 0 {
+		name, err := registerTLSConfig(cfg.CA, cfg.ServerName)
+		if err != nil {
+			return nil, err
+		}
+		mcfg.TLSConfig = name
+	}
+
+	return mcfg, nil
+}
+
+func timeout(t, def time.Duration) time.Duration {
+	switch {
+	case t == 0:
+		return def
+	case t < 0:
+		return 0
+	default:
+		return t
+	}
+}
+
+var (
+	tlsConfigsMx sync.Mutex
+	// tlsConfigs are the names of TLS configs registered in the driver
+	tlsConfigs = make(map[string]struct{})
+)
+
+// registerTLSConfig registers TLS config which verifies certificates with
+// the CA and the server name, and returns its name. The name is derived from
+// the CA and the server name, so the same config is registered once.
+func registerTLSConfig(ca []byte, serverName string) (string, error) {
+	name := fmt.Sprintf("ca-%x", sha256.Sum256(ca))
+	if serverName != "" {
+		name += "-" + serverName
+	}
+
+	tlsConfigsMx.Lock()
+	defer tlsConfigsMx.Unlock()
+
+	if _, ok := tlsConfigs[name]; ok {
+		return name, nil
+	}
+
+	tlsCfg, err := tlsConfig(ca, serverName)
+	if err != nil {
+		return "", err
+	}
+	if err := mysqldriver.RegisterTLSConfig(name, tlsCfg); err != nil {
+		return "", errors.Wrap(err, "register TLS config")
+	}
+	tlsConfigs[name] = struct{}{}
+
+	return name, nil
+}
+
+// tlsConfig returns TLS config which verifies certificates with the CA. If
+// serverName is empty, the driver verifies the host name of the address.
+func tlsConfig(ca []byte, serverName string) (*tls.Config, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("failed to parse CA certificate")
+	}
+
+	return &tls.Config{RootCAs: pool, ServerName: serverName}, nil
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/secret"
+)
+
+// clusterCerts returns data of the TLS secret generated for cluster1 in ns namespace
+func clusterCerts(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	cr := &apiv1alpha1.PerconaServerMySQL{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "ns"}}
+	s, err := secret.GenerateCertsSecret(context.Background(), cr)
+	if err != nil {
+		t.Fatalf("generate certificates: %v", err)
+	}
+	return s.Data
+}
+
+func TestDriverConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		addr         string
+		dialTimeout  time.Duration
+		readTimeout  time.Duration
+		writeTimeout time.Duration
+	}{
+		{
+			name:         "default timeouts",
+			cfg:          Config{User: apiv1alpha1.UserOperator, Password: "pass", Host: "10.0.0.1", Port: 33062},
+			addr:         "10.0.0.1:33062",
+			dialTimeout:  DefaultDialTimeout,
+			readTimeout:  DefaultReadTimeout,
+			writeTimeout: DefaultWriteTimeout,
+		},
+		{
+			name: "custom timeouts",
+			cfg: Config{
+				User: apiv1alpha1.UserOperator, Password: "pass", Host: "cluster1-mysql-0.cluster1-mysql.ns", Port: 3306,
+				DialTimeout: time.Second, ReadTimeout: 2 * time.Second, WriteTimeout: 3 * time.Second,
+			},
+			addr:         "cluster1-mysql-0.cluster1-mysql.ns:3306",
+			dialTimeout:  time.Second,
+			readTimeout:  2 * time.Second,
+			writeTimeout: 3 * time.Second,
+		},
+		{
+			name: "no timeout",
+			cfg: Config{
+				User: apiv1alpha1.UserOperator, Password: "pass", Host: "fd00::1", Port: 33062,
+				ReadTimeout: NoTimeout, WriteTimeout: NoTimeout,
+			},
+			addr:         "[fd00::1]:33062",
+			dialTimeout:  DefaultDialTimeout,
+			readTimeout:  0,
+			writeTimeout: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcfg, err := driverConfig(tt.cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mcfg.Addr != tt.addr {
+				t.Errorf("expected address %s, got %s", tt.addr, mcfg.Addr)
+			}
+			if mcfg.User != string(tt.cfg.User) || mcfg.Passwd != tt.cfg.Password {
+				t.Errorf("expected credentials of %s, got %s", tt.cfg.User, mcfg.User)
+			}
+			if mcfg.Timeout != tt.dialTimeout || mcfg.ReadTimeout != tt.readTimeout || mcfg.WriteTimeout != tt.writeTimeout {
+				t.Errorf("expected timeouts %s/%s/%s, got %s/%s/%s",
+					tt.dialTimeout, tt.readTimeout, tt.writeTimeout, mcfg.Timeout, mcfg.ReadTimeout, mcfg.WriteTimeout)
+			}
+			if mcfg.TLSConfig != "preferred" {
+				t.Errorf("expected preferred TLS without CA, got %s", mcfg.TLSConfig)
+			}
+
+			dsn := mcfg.FormatDSN()
+			for _, param := range []string{"interpolateParams=true", "tls=preferred", "/performance_schema?"} {
+				if !strings.Contains(dsn, param) {
+					t.Errorf("expected %s in DSN %s", param, dsn)
+				}
+			}
+			if tt.readTimeout == 0 && strings.Contains(dsn, "readTimeout") {
+				t.Errorf("expected no read timeout in DSN %s", dsn)
+			}
+		})
+	}
+}
+
+func TestDriverConfigTLS(t *testing.T) {
+	certs := clusterCerts(t)
+
+	cfg := Config{User: apiv1alpha1.UserOperator, Host: "10.0.0.1", Port: 33062, CA: certs["ca.crt"]}
+	mcfg, err := driverConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(mcfg.TLSConfig, "ca-") || strings.Contains(mcfg.TLSConfig, "cluster1") {
+		t.Errorf("expected TLS config of the CA without server name, got %s", mcfg.TLSConfig)
+	}
+
+	cfg.ServerName = "cluster1-mysql-0.cluster1-mysql.ns"
+	named, err := driverConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if named.TLSConfig == mcfg.TLSConfig || !strings.HasSuffix(named.TLSConfig, "-"+cfg.ServerName) {
+		t.Errorf("expected TLS config of the server name, got %s", named.TLSConfig)
+	}
+
+	again, err := driverConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.TLSConfig != named.TLSConfig {
+		t.Errorf("expected the same TLS config for the same CA and server name, got %s and %s", named.TLSConfig, again.TLSConfig)
+	}
+
+	cfg.CA = []byte("not a certificate")
+	if _, err := driverConfig(cfg); err == nil {
+		t.Error("expected error for invalid CA")
+	}
+}
+
+func TestTLSConfigVerifiesServerName(t *testing.T) {
+	certs := clusterCerts(t)
+	otherCerts := clusterCerts(t)
+
+	cert, err := tls.X509KeyPair(certs["tls.crt"], certs["tls.key"])
+	if err != nil {
+		t.Fatalf("load key pair: %v", err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_ = conn.(*tls.Conn).Handshake()
+			}(conn)
+		}
+	}()
+
+	tests := []struct {
+		name       string
+		ca         []byte
+		serverName string
+		valid      bool
+	}{
+		{"pod of MySQL service", certs["ca.crt"], "cluster1-mysql-0.cluster1-mysql.ns", true},
+		{"pod of unready service", certs["ca.crt"], "cluster1-mysql-0.cluster1-mysql-unready.ns", true},
+		{"FQDN of the pod", certs["ca.crt"], "cluster1-mysql-0.cluster1-mysql.ns.svc.cluster.local", true},
+		{"IP address", certs["ca.crt"], "", false},
+		{"other namespace", certs["ca.crt"], "cluster1-mysql-0.cluster1-mysql.other", false},
+		{"other cluster", certs["ca.crt"], "cluster2-mysql-0.cluster2-mysql.ns", false},
+		{"other CA", otherCerts["ca.crt"], "cluster1-mysql-0.cluster1-mysql.ns", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tlsConfig(tt.ca, tt.serverName)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.ServerName == "" {
+				// the driver uses the host of the address, see mysqldriver.Config
+				cfg.ServerName = "127.0.0.1"
+			}
+
+			conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", ln.Addr().String(), cfg)
+			if err == nil {
+				conn.Close()
+			}
+			if tt.valid && err != nil {
+				t.Errorf("expected certificate to be valid, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected certificate to be rejected")
+			}
+		})
+	}
+}
@@ -28,7 +28,9 @@ import (
 
 const WatchNamespaceEnvVar = "WATCH_NAMESPACE"
 
-// GetWatchNamespace returns the namespace the operator should be watching for changes
+// GetWatchNamespace returns the namespace the operator should be watching for changes.
+// It can be a single namespace, a comma-separated list of namespaces
+// or an empty string if the operator should watch all namespaces.
 func GetWatchNamespace() (string, error) {
 	ns, found := os.LookupEnv(WatchNamespaceEnvVar)
 	if !found {
@@ -37,6 +39,20 @@ func GetWatchNamespace() (string, error) {
 	return ns, nil
 }
 
+// WatchNamespaces splits the value of WATCH_NAMESPACE into a list of namespaces.
+// Empty list means all namespaces.
+func WatchNamespaces(ns string) []string {
+	namespaces := make([]string, 0)
+	for _, n := range strings.Split(ns, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		namespaces = append(namespaces, n)
+	}
+	return namespaces
+}
+
 func LabelsEqual(old, new metav1.Object) bool {
 	return util.SSMapEqual(old.GetLabels(), new.GetLabels())
 }
@@ -114,14 +130,16 @@ func EnsureObject(
 	return nil
 }
 
+// EnsureObjectWithHash creates the object owned by the owner or patches it if
+// its hash is changed
 func EnsureObjectWithHash(
 	ctx context.Context,
 	cl client.Client,
-	cr *apiv1alpha1.PerconaServerMySQL,
+	owner metav1.Object,
 	obj client.Object,
 	s *runtime.Scheme,
 ) error {
-	if err := controllerutil.SetControllerReference(cr, obj, s); err != nil {
+	if err := controllerutil.SetControllerReference(owner, obj, s); err != nil {
 		return errors.Wrapf(err, "set controller reference to %s/%s",
 			obj.GetObjectKind().GroupVersionKind().Kind,
 			obj.GetName())
@@ -220,10 +238,13 @@ func ObjectHash(obj runtime.Object) (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
-func PodsByLabels(ctx context.Context, cl client.Reader, l map[string]string) ([]corev1.Pod, error) {
+func PodsByLabels(ctx context.Context, cl client.Reader, l map[string]string, namespace string) ([]corev1.Pod, error) {
 	podList := &corev1.PodList{}
 
-	opts := &client.ListOptions{LabelSelector: labels.SelectorFromSet(l)}
+	opts := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: labels.SelectorFromSet(l),
+	}
 	if err := cl.List(ctx, podList, opts); err != nil {
 		return nil, err
 	}
@@ -231,10 +252,13 @@ func PodsByLabels(ctx context.Context, cl client.Reader, l map[string]string) ([
 	return podList.Items, nil
 }
 
-func ServicesByLabels(ctx context.Context, cl client.Reader, l map[string]string) ([]corev1.Service, error) {
+func ServicesByLabels(ctx context.Context, cl client.Reader, l map[string]string, namespace string) ([]corev1.Service, error) {
 	svcList := &corev1.ServiceList{}
 
-	opts := &client.ListOptions{LabelSelector: labels.SelectorFromSet(l)}
+	opts := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: labels.SelectorFromSet(l),
+	}
 	if err := cl.List(ctx, svcList, opts); err != nil {
 		return nil, err
 	}
@@ -242,6 +266,34 @@ func ServicesByLabels(ctx context.Context, cl client.Reader, l map[string]string
 	return svcList.Items, nil
 }
 
+func StatefulSetsByLabels(ctx context.Context, cl client.Reader, l map[string]string, namespace string) ([]appsv1.StatefulSet, error) {
+	stsList := &appsv1.StatefulSetList{}
+
+	opts := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: labels.SelectorFromSet(l),
+	}
+	if err := cl.List(ctx, stsList, opts); err != nil {
+		return nil, err
+	}
+
+	return stsList.Items, nil
+}
+
+func PVCsByLabels(ctx context.Context, cl client.Reader, l map[string]string, namespace string) ([]corev1.PersistentVolumeClaim, error) {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+
+	opts := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: labels.SelectorFromSet(l),
+	}
+	if err := cl.List(ctx, pvcList, opts); err != nil {
+		return nil, err
+	}
+
+	return pvcList.Items, nil
+}
+
 // DefaultAPINamespace returns namespace for direct api access from a pod
 // https://v1-21.docs.kubernetes.io/docs/tasks/run-application/access-api-from-pod/#directly-accessing-the-rest-api
 func DefaultAPINamespace() (string, error) {
@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+const prefix = "percona_server_mysql_"
+
+var (
+	clusterState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "cluster_state",
+			Help: "Current state of the cluster. The series with the current state has value 1, others have 0.",
+		},
+		[]string{"namespace", "name", "state"},
+	)
+
+	readyPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "ready_pods",
+			Help: "Number of ready pods per cluster component.",
+		},
+		[]string{"namespace", "name", "component"},
+	)
+
+	desiredPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "desired_pods",
+			Help: "Number of desired pods per cluster component.",
+		},
+		[]string{"namespace", "name", "component"},
+	)
+
+	primary = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "primary",
+			Help: "Current primary of the cluster. The series with the current primary pod has value 1.",
+		},
+		[]string{"namespace", "name", "pod"},
+	)
+
+	failovers = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "failovers_total",
+			Help: "Number of primary changes observed by the operator.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	passwordRotations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "password_rotations_total",
+			Help: "Number of completed system user password rotations.",
+		},
+		[]string{"namespace", "name", "user"},
+	)
+
+	reconcilePhaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prefix + "reconcile_phase_duration_seconds",
+			Help:    "Duration of reconcile phases.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"namespace", "name", "phase"},
+	)
+
+	orchestratorAPIErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "orchestrator_api_errors_total",
+			Help: "Number of failed requests to Orchestrator API.",
+		},
+		[]string{"endpoint"},
+	)
+)
+
+var states = []apiv1alpha1.StatefulAppState{
+	apiv1alpha1.StateInitializing,
+	apiv1alpha1.StateReady,
+}
+
+// primaries keeps the last observed primary pod per cluster
+// to be able to drop outdated series of the primary gauge.
+var (
+	primaries   = make(map[string]string)
+	primariesMx sync.Mutex
+)
+
+// users and phases keep label values of the counters and histograms
+// per cluster to be able to drop their series when the cluster is deleted
+var (
+	users    = make(map[string]map[string]struct{})
+	phases   = make(map[string]map[string]struct{})
+	labelsMx sync.Mutex
+)
+
+func clusterKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func addLabelValue(values map[string]map[string]struct{}, key, value string) {
+	labelsMx.Lock()
+	defer labelsMx.Unlock()
+
+	if values[key] == nil {
+		values[key] = make(map[string]struct{})
+	}
+	values[key][value] = struct{}{}
+}
+
+// popLabelValues returns the label values of the cluster and forgets them
+func popLabelValues(values map[string]map[string]struct{}, key string) []string {
+	labelsMx.Lock()
+	defer labelsMx.Unlock()
+
+	list := make([]string, 0, len(values[key]))
+	for v := range values[key] {
+		list = append(list, v)
+	}
+	delete(values, key)
+
+	return list
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		clusterState,
+		readyPods,
+		desiredPods,
+		primary,
+		failovers,
+		passwordRotations,
+		reconcilePhaseDuration,
+		orchestratorAPIErrors,
+	)
+}
+
+// SetClusterState sets cluster state and the number of desired and ready pods per component
+func SetClusterState(cr *apiv1alpha1.PerconaServerMySQL) {
+	for _, s := range states {
+		v := 0.0
+		if s == cr.Status.State {
+			v = 1
+		}
+		clusterState.WithLabelValues(cr.Namespace, cr.Name, string(s)).Set(v)
+	}
+
+	components := map[string]apiv1alpha1.StatefulAppStatus{
+		"mysql":        cr.Status.MySQL,
+		"orchestrator": cr.Status.Orchestrator,
+	}
+	for component, status := range components {
+		readyPods.WithLabelValues(cr.Namespace, cr.Name, component).Set(float64(status.Ready))
+		desiredPods.WithLabelValues(cr.Namespace, cr.Name, component).Set(float64(status.Size))
+	}
+}
+
+// SetPrimary sets current primary of the cluster. Failovers are counted by
+// IncFailovers when the primary label is moved to another pod, so they are
+// counted after the operator restarts too.
+func SetPrimary(cr *apiv1alpha1.PerconaServerMySQL, pod string) {
+	primariesMx.Lock()
+	defer primariesMx.Unlock()
+
+	key := clusterKey(cr.Namespace, cr.Name)
+	prev, ok := primaries[key]
+	if ok && prev == pod {
+		return
+	}
+
+	if ok {
+		primary.DeleteLabelValues(cr.Namespace, cr.Name, prev)
+	}
+	primary.WithLabelValues(cr.Namespace, cr.Name, pod).Set(1)
+	primaries[key] = pod
+}
+
+// IncFailovers increments the number of observed failovers
+func IncFailovers(cr *apiv1alpha1.PerconaServerMySQL) {
+	failovers.WithLabelValues(cr.Namespace, cr.Name).Inc()
+}
+
+// IncPasswordRotations increments the number of password rotations of the user
+func IncPasswordRotations(cr *apiv1alpha1.PerconaServerMySQL, user string) {
+	addLabelValue(users, clusterKey(cr.Namespace, cr.Name), user)
+	passwordRotations.WithLabelValues(cr.Namespace, cr.Name, user).Inc()
+}
+
+// ObserveReconcilePhase records duration of the reconcile phase
+func ObserveReconcilePhase(cr *apiv1alpha1.PerconaServerMySQL, phase string, d time.Duration) {
+	addLabelValue(phases, clusterKey(cr.Namespace, cr.Name), phase)
+	reconcilePhaseDuration.WithLabelValues(cr.Namespace, cr.Name, phase).Observe(d.Seconds())
+}
+
+// IncOrchestratorAPIErrors increments the number of failed requests to the Orchestrator API endpoint
+func IncOrchestratorAPIErrors(endpoint string) {
+	orchestratorAPIErrors.WithLabelValues(endpoint).Inc()
+}
+
+// DeleteCluster removes all series of the deleted cluster
+func DeleteCluster(namespace, name string) {
+	for _, s := range states {
+		clusterState.DeleteLabelValues(namespace, name, string(s))
+	}
+
+	for _, component := range []string{"mysql", "orchestrator"} {
+		readyPods.DeleteLabelValues(namespace, name, component)
+		desiredPods.DeleteLabelValues(namespace, name, component)
+	}
+
+	key := clusterKey(namespace, name)
+
+	primariesMx.Lock()
+	if pod, ok := primaries[key]; ok {
+		primary.DeleteLabelValues(namespace, name, pod)
+		delete(primaries, key)
+	}
+	primariesMx.Unlock()
+
+	failovers.DeleteLabelValues(namespace, name)
+
+	for _, user := range popLabelValues(users, key) {
+		passwordRotations.DeleteLabelValues(namespace, name, user)
+	}
+	for _, phase := range popLabelValues(phases, key) {
+		reconcilePhaseDuration.DeleteLabelValues(namespace, name, phase)
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+// series returns the series of the collector with the namespace and name labels
+func series(t *testing.T, c prometheus.Collector, namespace, name string) []*dto.Metric {
+	t.Helper()
+
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+
+	result := make([]*dto.Metric, 0)
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatalf("write metric: %v", err)
+		}
+
+		labels := make(map[string]string)
+		for _, l := range pb.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if labels["namespace"] == namespace && labels["name"] == name {
+			result = append(result, pb)
+		}
+	}
+
+	return result
+}
+
+func newCluster(namespace, name string) *apiv1alpha1.PerconaServerMySQL {
+	return &apiv1alpha1.PerconaServerMySQL{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func TestSetPrimary(t *testing.T) {
+	cr := newCluster("test-set-primary", "cluster1")
+	defer DeleteCluster(cr.Namespace, cr.Name)
+
+	SetPrimary(cr, "cluster1-mysql-0")
+	SetPrimary(cr, "cluster1-mysql-0")
+	SetPrimary(cr, "cluster1-mysql-1")
+
+	got := series(t, primary, cr.Namespace, cr.Name)
+	if len(got) != 1 {
+		t.Fatalf("expected one primary series, got %d", len(got))
+	}
+	for _, l := range got[0].GetLabel() {
+		if l.GetName() == "pod" && l.GetValue() != "cluster1-mysql-1" {
+			t.Errorf("expected primary cluster1-mysql-1, got %s", l.GetValue())
+		}
+	}
+
+	if got := series(t, failovers, cr.Namespace, cr.Name); len(got) != 0 {
+		t.Errorf("failovers are counted by IncFailovers only, got %d series", len(got))
+	}
+}
+
+func TestDeleteCluster(t *testing.T) {
+	cr := newCluster("test-delete-cluster", "cluster1")
+	cr.Status.State = apiv1alpha1.StateReady
+
+	SetClusterState(cr)
+	SetPrimary(cr, "cluster1-mysql-0")
+	IncFailovers(cr)
+	IncPasswordRotations(cr, string(apiv1alpha1.UserOperator))
+	IncPasswordRotations(cr, string(apiv1alpha1.UserMonitor))
+	ObserveReconcilePhase(cr, "users", time.Second)
+	ObserveReconcilePhase(cr, "replication", time.Second)
+
+	other := newCluster("test-delete-cluster", "cluster2")
+	defer DeleteCluster(other.Namespace, other.Name)
+	IncPasswordRotations(other, string(apiv1alpha1.UserOperator))
+
+	DeleteCluster(cr.Namespace, cr.Name)
+
+	collectors := map[string]prometheus.Collector{
+		"cluster_state":                    clusterState,
+		"ready_pods":                       readyPods,
+		"desired_pods":                     desiredPods,
+		"primary":                          primary,
+		"failovers_total":                  failovers,
+		"password_rotations_total":         passwordRotations,
+		"reconcile_phase_duration_seconds": reconcilePhaseDuration,
+	}
+	for name, c := range collectors {
+		if got := series(t, c, cr.Namespace, cr.Name); len(got) != 0 {
+			t.Errorf("%s: expected no series of the deleted cluster, got %d", name, len(got))
+		}
+	}
+
+	if got := series(t, passwordRotations, other.Namespace, other.Name); len(got) != 1 {
+		t.Errorf("expected series of the other cluster to be kept, got %d", len(got))
+	}
+}
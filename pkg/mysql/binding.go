@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+// BindingSecretType is the type of binding Secrets recommended by the Service Binding specification
+const BindingSecretType corev1.SecretType = "servicebinding.io/mysql"
+
+// BindingSecret returns the Secret with connection details of the user. Keys
+// follow well-known entries of the Service Binding specification. Writes go
+// to the primary service and reads can go to the service of all instances.
+func BindingSecret(cr *apiv1alpha1.PerconaServerMySQL, name, username, password, database string, ca []byte) *corev1.Secret {
+	host := fmt.Sprintf("%s.%s", PrimaryServiceName(cr), cr.Namespace)
+	port := strconv.Itoa(DefaultPort)
+	hostPort := net.JoinHostPort(host, port)
+
+	uri := url.URL{
+		Scheme: "mysql",
+		User:   url.UserPassword(username, password),
+		Host:   hostPort,
+		Path:   "/" + database,
+	}
+
+	data := map[string][]byte{
+		"type":           []byte("mysql"),
+		"provider":       []byte("percona"),
+		"host":           []byte(host),
+		"port":           []byte(port),
+		"read-only-host": []byte(fmt.Sprintf("%s.%s", ServiceName(cr), cr.Namespace)),
+		"username":       []byte(username),
+		"password":       []byte(password),
+		"uri":            []byte(uri.String()),
+		"jdbc-url":       []byte(fmt.Sprintf("jdbc:mysql://%s/%s", hostPort, database)),
+	}
+	if database != "" {
+		data["database"] = []byte(database)
+	}
+	if len(ca) > 0 {
+		data["ca.crt"] = ca
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels:    cr.Labels(),
+		},
+		Type: BindingSecretType,
+		Data: data,
+	}
+}
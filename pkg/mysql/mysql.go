@@ -1,6 +1,9 @@
 package mysql
 
 import (
+	"fmt"
+	"strconv"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,16 +15,31 @@ import (
 )
 
 const (
-	componentName    = "mysql"
-	dataVolumeName   = "datadir"
-	DataMountPath    = "/var/lib/mysql"
-	CustomConfigKey  = "my.cnf"
-	configVolumeName = "config"
-	configMountPath  = "/etc/mysql/config"
-	credsVolumeName  = "users"
-	CredsMountPath   = "/etc/mysql/mysql-users-secret"
-	tlsVolumeName    = "tls"
-	tlsMountPath     = "/etc/mysql/mysql-tls-secret"
+	componentName     = "mysql"
+	dataVolumeName    = "datadir"
+	DataMountPath     = "/var/lib/mysql"
+	CustomConfigKey   = "my.cnf"
+	configVolumeName  = "config"
+	configMountPath   = "/etc/mysql/config"
+	credsVolumeName   = "users"
+	CredsMountPath    = "/etc/mysql/mysql-users-secret"
+	tlsVolumeName     = "tls"
+	tlsMountPath      = "/etc/mysql/mysql-tls-secret"
+	podInfoVolumeName = "podinfo"
+	PodInfoMountPath  = "/etc/mysql/podinfo"
+	PodLabelsFileName = "labels"
+
+	// MaxReplicationLagEnv is checked by readiness probe of replicas
+	MaxReplicationLagEnv = "MAX_REPLICATION_LAG"
+
+	// Migration*Env configure bootstrap of the primary to clone from spec.migration
+	MigrationHostEnv     = "MIGRATION_SOURCE_HOST"
+	MigrationPortEnv     = "MIGRATION_SOURCE_PORT"
+	MigrationUserEnv     = "MIGRATION_SOURCE_USER"
+	MigrationPasswordEnv = "MIGRATION_SOURCE_PASSWORD"
+
+	// TLSCAFile verifies certificates of instances in bootstrap and healthcheck
+	TLSCAFile = tlsMountPath + "/ca.crt"
 )
 
 const (
@@ -85,15 +103,81 @@ func ConfigMapName(cr *apiv1alpha1.PerconaServerMySQL) string {
 	return Name(cr)
 }
 
+func PodName(cr *apiv1alpha1.PerconaServerMySQL, idx int) string {
+	return fmt.Sprintf("%s-%d", Name(cr), idx)
+}
+
+// FQDN returns hostname of the pod. It's the same as report_host set in ps-entrypoint.sh.
+func FQDN(cr *apiv1alpha1.PerconaServerMySQL, idx int) string {
+	return fmt.Sprintf("%s.%s.%s", PodName(cr, idx), ServiceName(cr), cr.Namespace)
+}
+
 func MatchLabels(cr *apiv1alpha1.PerconaServerMySQL) map[string]string {
 	return util.SSMapMerge(cr.MySQLSpec().Labels,
 		map[string]string{apiv1alpha1.ComponentLabel: componentName},
 		cr.Labels())
 }
 
+// instanceSet is a StatefulSet of MySQL instances, the main one or a replica pool
+type instanceSet struct {
+	// name of the StatefulSet and its governing service
+	name         string
+	labels       map[string]string
+	spec         *apiv1alpha1.MySQLSpec
+	serverIDHash string
+	// env is appended to the environment of mysqld container
+	env []corev1.EnvVar
+}
+
+func mainInstanceSet(cr *apiv1alpha1.PerconaServerMySQL) instanceSet {
+	return instanceSet{
+		name:         Name(cr),
+		labels:       MatchLabels(cr),
+		spec:         cr.MySQLSpec(),
+		serverIDHash: cr.ClusterHash(),
+		env:          migrationEnv(cr),
+	}
+}
+
+// migrationEnv returns the environment for bootstrap to clone from spec.migration.
+// It's empty after cutover, so instances are never cloned from the external server again.
+func migrationEnv(cr *apiv1alpha1.PerconaServerMySQL) []corev1.EnvVar {
+	m := cr.Spec.Migration
+	if m == nil || m.Cutover {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  MigrationHostEnv,
+			Value: m.Host,
+		},
+		{
+			Name:  MigrationPortEnv,
+			Value: strconv.Itoa(int(m.Port)),
+		},
+		{
+			Name: MigrationUserEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: k8s.SecretKeySelector(m.CredentialsSecret, "username"),
+			},
+		},
+		{
+			Name: MigrationPasswordEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: k8s.SecretKeySelector(m.CredentialsSecret, "password"),
+			},
+		},
+	}
+}
+
 func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash string) *appsv1.StatefulSet {
-	labels := MatchLabels(cr)
-	spec := cr.MySQLSpec()
+	return statefulSet(cr, mainInstanceSet(cr), initImage, configHash)
+}
+
+func statefulSet(cr *apiv1alpha1.PerconaServerMySQL, set instanceSet, initImage, configHash string) *appsv1.StatefulSet {
+	labels := set.labels
+	spec := set.spec
 	replicas := spec.Size
 	t := true
 
@@ -106,7 +190,7 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash strin
 			Kind:       "StatefulSet",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      Name(cr),
+			Name:      set.name,
 			Namespace: cr.Namespace,
 			Labels:    labels,
 		},
@@ -115,7 +199,7 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash strin
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
-			ServiceName:          ServiceName(cr),
+			ServiceName:          set.name,
 			VolumeClaimTemplates: volumeClaimTemplates(spec),
 			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
 				Type: appsv1.RollingUpdateStatefulSetStrategyType,
@@ -126,8 +210,8 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash strin
 					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
-					NodeSelector: cr.Spec.MySQL.NodeSelector,
-					Tolerations:  cr.Spec.MySQL.Tolerations,
+					NodeSelector: spec.NodeSelector,
+					Tolerations:  spec.Tolerations,
 					InitContainers: []corev1.Container{
 						{
 							Name:            componentName + "-init",
@@ -153,7 +237,7 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash strin
 							SecurityContext:          spec.ContainerSecurityContext,
 						},
 					},
-					Containers:       containers(cr),
+					Containers:       containers(cr, set),
 					Affinity:         spec.GetAffinity(labels),
 					ImagePullSecrets: spec.ImagePullSecrets,
 					// TerminationGracePeriodSeconds: 30,
@@ -178,6 +262,21 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash strin
 									},
 								},
 							},
+							{
+								Name: podInfoVolumeName,
+								VolumeSource: corev1.VolumeSource{
+									DownwardAPI: &corev1.DownwardAPIVolumeSource{
+										Items: []corev1.DownwardAPIVolumeFile{
+											{
+												Path: PodLabelsFileName,
+												FieldRef: &corev1.ObjectFieldSelector{
+													FieldPath: "metadata.labels",
+												},
+											},
+										},
+									},
+								},
+							},
 							{
 								Name: configVolumeName,
 								VolumeSource: corev1.VolumeSource{
@@ -395,20 +494,20 @@ func PrimaryService(cr *apiv1alpha1.PerconaServerMySQL) *corev1.Service {
 	}
 }
 
-func containers(cr *apiv1alpha1.PerconaServerMySQL) []corev1.Container {
-	containers := []corev1.Container{mysqldContainer(cr)}
+func containers(cr *apiv1alpha1.PerconaServerMySQL, set instanceSet) []corev1.Container {
+	containers := []corev1.Container{mysqldContainer(cr, set)}
 	if pmm := cr.PMMSpec(); pmm != nil && pmm.Enabled {
 		c := pmmContainer(cr.Name, cr.Spec.SecretsName, pmm)
 		containers = append(containers, c)
 	}
 
-	return appendUniqueContainers(containers, cr.MySQLSpec().Sidecars...)
+	return appendUniqueContainers(containers, set.spec.Sidecars...)
 }
 
-func mysqldContainer(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
-	spec := cr.MySQLSpec()
+func mysqldContainer(cr *apiv1alpha1.PerconaServerMySQL, set instanceSet) corev1.Container {
+	spec := set.spec
 
-	return corev1.Container{
+	c := corev1.Container{
 		Name:            componentName,
 		Image:           spec.Image,
 		ImagePullPolicy: spec.ImagePullPolicy,
@@ -420,7 +519,7 @@ func mysqldContainer(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 			},
 			{
 				Name:  "SERVICE_NAME",
-				Value: ServiceName(cr),
+				Value: set.name,
 			},
 			{
 				Name:  "SERVICE_NAME_UNREADY",
@@ -428,7 +527,7 @@ func mysqldContainer(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 			},
 			{
 				Name:  "CLUSTER_HASH",
-				Value: cr.ClusterHash(),
+				Value: set.serverIDHash,
 			},
 		},
 		Ports: []corev1.ContainerPort{
@@ -462,6 +561,10 @@ func mysqldContainer(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 				Name:      configVolumeName,
 				MountPath: configMountPath,
 			},
+			{
+				Name:      podInfoVolumeName,
+				MountPath: PodInfoMountPath,
+			},
 		},
 		Command:                  []string{"/var/lib/mysql/ps-entrypoint.sh"},
 		Args:                     []string{"mysqld"},
@@ -508,6 +611,16 @@ func mysqldContainer(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 			TerminationGracePeriodSeconds: spec.ReadinessProbe.TerminationGracePeriodSeconds,
 		},
 	}
+
+	if spec.MaxReplicationLag > 0 {
+		c.Env = append(c.Env, corev1.EnvVar{
+			Name:  MaxReplicationLagEnv,
+			Value: strconv.FormatInt(spec.MaxReplicationLag, 10),
+		})
+	}
+	c.Env = append(c.Env, set.env...)
+
+	return c
 }
 
 func pmmContainer(clusterName, secretsName string, pmmSpec *apiv1alpha1.PMMSpec) corev1.Container {
@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/util"
+)
+
+const replicaPoolComponentName = componentName + "-replica-pool"
+
+// ReplicaPoolName returns name of the StatefulSet and the Service of the replica pool
+func ReplicaPoolName(cr *apiv1alpha1.PerconaServerMySQL, pool string) string {
+	return Name(cr) + "-" + pool
+}
+
+func ReplicaPoolNamespacedName(cr *apiv1alpha1.PerconaServerMySQL, pool string) types.NamespacedName {
+	return types.NamespacedName{Name: ReplicaPoolName(cr, pool), Namespace: cr.Namespace}
+}
+
+// ReplicaPoolsLabels returns labels of all replica pools of the cluster
+func ReplicaPoolsLabels(cr *apiv1alpha1.PerconaServerMySQL) map[string]string {
+	return util.SSMapMerge(map[string]string{apiv1alpha1.ComponentLabel: replicaPoolComponentName}, cr.Labels())
+}
+
+func ReplicaPoolMatchLabels(cr *apiv1alpha1.PerconaServerMySQL, pool *apiv1alpha1.ReplicaPoolSpec) map[string]string {
+	return util.SSMapMerge(pool.Labels,
+		map[string]string{
+			apiv1alpha1.ComponentLabel:        replicaPoolComponentName,
+			apiv1alpha1.MySQLReplicaPoolLabel: pool.Name,
+		},
+		cr.Labels())
+}
+
+// IsReplicaPoolPod returns true if the pod belongs to a replica pool of the cluster.
+// Pool names start with a letter, so they can't be confused with ordinals of the main StatefulSet.
+func IsReplicaPoolPod(cr *apiv1alpha1.PerconaServerMySQL, podName string) bool {
+	prefix := Name(cr) + "-"
+	if !strings.HasPrefix(podName, prefix) {
+		return false
+	}
+
+	return strings.Contains(strings.TrimPrefix(podName, prefix), "-")
+}
+
+func replicaPoolInstanceSet(cr *apiv1alpha1.PerconaServerMySQL, pool *apiv1alpha1.ReplicaPoolSpec) instanceSet {
+	spec := *cr.MySQLSpec()
+	spec.PodSpec = pool.PodSpec
+	if spec.MaxReplicationLag > 0 {
+		// heartbeat lag of delayed replicas includes the delay
+		spec.MaxReplicationLag += int64(pool.SourceDelay)
+	}
+
+	return instanceSet{
+		name:         ReplicaPoolName(cr, pool.Name),
+		labels:       ReplicaPoolMatchLabels(cr, pool),
+		spec:         &spec,
+		serverIDHash: cr.ReplicaPoolHash(pool.Name),
+	}
+}
+
+// ReplicaPoolStatefulSet returns StatefulSet of the replica pool. Pool pods find
+// the primary and the clone donor using unready service of the main StatefulSet.
+func ReplicaPoolStatefulSet(cr *apiv1alpha1.PerconaServerMySQL, pool *apiv1alpha1.ReplicaPoolSpec, initImage, configHash string) *appsv1.StatefulSet {
+	return statefulSet(cr, replicaPoolInstanceSet(cr, pool), initImage, configHash)
+}
+
+// ReplicaPoolService returns governing headless service of the replica pool
+func ReplicaPoolService(cr *apiv1alpha1.PerconaServerMySQL, pool *apiv1alpha1.ReplicaPoolSpec) *corev1.Service {
+	labels := ReplicaPoolMatchLabels(cr, pool)
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReplicaPoolName(cr, pool.Name),
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "None",
+			Ports: []corev1.ServicePort{
+				{
+					Name: "mysql",
+					Port: DefaultPort,
+				},
+				{
+					Name: "mysql-admin",
+					Port: DefaultAdminPort,
+				},
+				{
+					Name: "mysqlx",
+					Port: DefaultXPort,
+				},
+			},
+			Selector: labels,
+		},
+	}
+}
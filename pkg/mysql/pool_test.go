@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+func newCluster() *apiv1alpha1.PerconaServerMySQL {
+	cr := &apiv1alpha1.PerconaServerMySQL{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "ns", UID: "uid"}}
+	cr.Spec.MySQL.Size = 3
+	return cr
+}
+
+func TestIsReplicaPoolPod(t *testing.T) {
+	cr := newCluster()
+
+	tests := []struct {
+		pod      string
+		expected bool
+	}{
+		{"cluster1-mysql-0", false},
+		{"cluster1-mysql-12", false},
+		{"cluster1-mysql-delayed-0", true},
+		{"cluster1-mysql-reporting-eu-1", true},
+		{"cluster1-orc-0", false},
+		{"cluster2-mysql-delayed-0", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsReplicaPoolPod(cr, tt.pod); got != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.pod, tt.expected, got)
+		}
+	}
+}
+
+func TestReplicaPoolInstanceSet(t *testing.T) {
+	cr := newCluster()
+	cr.Spec.MySQL.MaxReplicationLag = 60
+	pool := &apiv1alpha1.ReplicaPoolSpec{
+		Name:        "delayed",
+		SourceDelay: 3600,
+		PodSpec:     apiv1alpha1.PodSpec{Size: 1, Labels: map[string]string{"tier": "delayed"}},
+	}
+
+	set := replicaPoolInstanceSet(cr, pool)
+
+	if set.name != "cluster1-mysql-delayed" {
+		t.Errorf("expected name cluster1-mysql-delayed, got %s", set.name)
+	}
+	if set.spec.Size != 1 {
+		t.Errorf("expected size of the pool, got %d", set.spec.Size)
+	}
+	if set.spec.MaxReplicationLag != 3660 {
+		t.Errorf("expected max lag to include the delay, got %d", set.spec.MaxReplicationLag)
+	}
+	if cr.Spec.MySQL.Size != 3 || cr.Spec.MySQL.MaxReplicationLag != 60 {
+		t.Error("expected spec of the cluster to be unchanged")
+	}
+	if set.serverIDHash == mainInstanceSet(cr).serverIDHash {
+		t.Error("expected server IDs of the pool to differ from the main StatefulSet")
+	}
+
+	for k, v := range map[string]string{
+		apiv1alpha1.ComponentLabel:        replicaPoolComponentName,
+		apiv1alpha1.MySQLReplicaPoolLabel: "delayed",
+		apiv1alpha1.InstanceLabel:         "cluster1",
+		"tier":                            "delayed",
+	} {
+		if set.labels[k] != v {
+			t.Errorf("expected label %s=%s, got %q", k, v, set.labels[k])
+		}
+	}
+
+	pool.SourceDelay = 0
+	cr.Spec.MySQL.MaxReplicationLag = 0
+	if lag := replicaPoolInstanceSet(cr, pool).spec.MaxReplicationLag; lag != 0 {
+		t.Errorf("expected no max lag if it is not set for the cluster, got %d", lag)
+	}
+}
+
+func TestReplicaPoolService(t *testing.T) {
+	cr := newCluster()
+	pool := &apiv1alpha1.ReplicaPoolSpec{Name: "delayed"}
+
+	svc := ReplicaPoolService(cr, pool)
+
+	if svc.Name != "cluster1-mysql-delayed" || svc.Namespace != "ns" {
+		t.Errorf("expected ns/cluster1-mysql-delayed, got %s/%s", svc.Namespace, svc.Name)
+	}
+	if svc.Spec.ClusterIP != "None" {
+		t.Errorf("expected headless service, got cluster IP %s", svc.Spec.ClusterIP)
+	}
+	if svc.Spec.Selector[apiv1alpha1.MySQLReplicaPoolLabel] != "delayed" {
+		t.Errorf("expected selector of the pool, got %v", svc.Spec.Selector)
+	}
+	for _, port := range []int32{DefaultPort, DefaultAdminPort, DefaultXPort} {
+		found := false
+		for _, p := range svc.Spec.Ports {
+			found = found || p.Port == port
+		}
+		if !found {
+			t.Errorf("expected port %d, got %v", port, svc.Spec.Ports)
+		}
+	}
+}
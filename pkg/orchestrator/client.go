@@ -6,10 +6,41 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/percona/percona-server-mysql-operator/pkg/metrics"
+	"github.com/percona/percona-server-mysql-operator/pkg/util"
 )
 
+// API is a client of Orchestrator HTTP API
+type API struct {
+	// Host is the API address, e.g. https://cluster1-orc-0.cluster1-orc.ns:3000
+	Host     string
+	User     string
+	Password string
+
+	client *http.Client
+}
+
+// NewAPI returns a client of Orchestrator HTTP API. Requests are authenticated
+// with basic auth if user is not empty. Server certificate is verified with
+// caCert if it's not empty, otherwise system root CAs are used.
+func NewAPI(host, user, password string, caCert []byte) (*API, error) {
+	client, err := util.HTTPClient(caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &API{
+		Host:     host,
+		User:     user,
+		Password: password,
+		client:   client,
+	}, nil
+}
+
 type orcResponse struct {
 	Code    string      `json:"Code"`
 	Message string      `json:"Message"`
@@ -28,10 +59,10 @@ type Instance struct {
 	Replicas  []InstanceKey `json:"Replicas"`
 }
 
-func ClusterPrimary(ctx context.Context, apiHost, clusterHint string) (*Instance, error) {
-	url := fmt.Sprintf("%s/api/master/%s", apiHost, clusterHint)
+func ClusterPrimary(ctx context.Context, api *API, clusterHint string) (*Instance, error) {
+	url := fmt.Sprintf("%s/api/master/%s", api.Host, clusterHint)
 
-	resp, err := doRequest(ctx, url)
+	resp, err := doRequest(ctx, api, url)
 	if err != nil {
 		return nil, errors.Wrapf(err, "do request to %s", url)
 	}
@@ -59,10 +90,71 @@ func ClusterPrimary(ctx context.Context, apiHost, clusterHint string) (*Instance
 	return primary, nil
 }
 
-func StopReplication(ctx context.Context, apiHost, host string, port int32) error {
-	url := fmt.Sprintf("%s/api/stop-replica/%s/%d", apiHost, host, port)
+func ClusterInstances(ctx context.Context, api *API, clusterHint string) ([]*Instance, error) {
+	url := fmt.Sprintf("%s/api/cluster/%s", api.Host, clusterHint)
+
+	resp, err := doRequest(ctx, api, url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "do request to %s", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response body")
+	}
+
+	instances := make([]*Instance, 0)
+	if err := json.Unmarshal(body, &instances); err == nil {
+		return instances, nil
+	}
+
+	orcResp := &orcResponse{}
+	if err := json.Unmarshal(body, orcResp); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+
+	if orcResp.Code == "ERROR" {
+		return nil, errors.New(orcResp.Message)
+	}
+
+	return instances, nil
+}
+
+// AllInstances returns all instances known to Orchestrator
+func AllInstances(ctx context.Context, api *API) ([]*Instance, error) {
+	url := fmt.Sprintf("%s/api/all-instances", api.Host)
 
-	resp, err := doRequest(ctx, url)
+	resp, err := doRequest(ctx, api, url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "do request to %s", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response body")
+	}
+
+	instances := make([]*Instance, 0)
+	if err := json.Unmarshal(body, &instances); err == nil {
+		return instances, nil
+	}
+
+	orcResp := &orcResponse{}
+	if err := json.Unmarshal(body, orcResp); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+
+	return nil, errors.New(orcResp.Message)
+}
+
+// GracefulMasterTakeover promotes the designated replica to be the new primary of the cluster.
+// Old primary is demoted and starts replicating from the new one.
+func GracefulMasterTakeover(ctx context.Context, api *API, clusterHint, host string, port int32) error {
+	url := fmt.Sprintf("%s/api/graceful-master-takeover/%s/%s/%d", api.Host, clusterHint, host, port)
+
+	resp, err := doRequest(ctx, api, url)
 	if err != nil {
 		return errors.Wrapf(err, "do request to %s", url)
 	}
@@ -80,10 +172,11 @@ func StopReplication(ctx context.Context, apiHost, host string, port int32) erro
 	return nil
 }
 
-func StartReplication(ctx context.Context, apiHost, host string, port int32) error {
-	url := fmt.Sprintf("%s/api/start-replica/%s/%d", apiHost, host, port)
+// Discover registers the instance in the Orchestrator topology
+func Discover(ctx context.Context, api *API, host string, port int32) error {
+	url := fmt.Sprintf("%s/api/discover/%s/%d", api.Host, host, port)
 
-	resp, err := doRequest(ctx, url)
+	resp, err := doRequest(ctx, api, url)
 	if err != nil {
 		return errors.Wrapf(err, "do request to %s", url)
 	}
@@ -101,10 +194,97 @@ func StartReplication(ctx context.Context, apiHost, host string, port int32) err
 	return nil
 }
 
-func AddPeer(ctx context.Context, apiHost string, peer string) error {
-	url := fmt.Sprintf("%s/api/raft-add-peer/%s", apiHost, peer)
+// Forget removes the instance from the Orchestrator topology
+func Forget(ctx context.Context, api *API, host string, port int32) error {
+	url := fmt.Sprintf("%s/api/forget/%s/%d", api.Host, host, port)
 
-	resp, err := doRequest(ctx, url)
+	resp, err := doRequest(ctx, api, url)
+	if err != nil {
+		return errors.Wrapf(err, "do request to %s", url)
+	}
+	defer resp.Body.Close()
+
+	orcResp := &orcResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(orcResp); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+
+	if orcResp.Code == "ERROR" {
+		return errors.New(orcResp.Message)
+	}
+
+	return nil
+}
+
+// RegisterCandidate sets promotion rule of the instance. Registration expires
+// after CandidateInstanceExpireMinutes, so it should be renewed periodically.
+func RegisterCandidate(ctx context.Context, api *API, host string, port int32, promotionRule string) error {
+	url := fmt.Sprintf("%s/api/register-candidate/%s/%d/%s", api.Host, host, port, promotionRule)
+
+	resp, err := doRequest(ctx, api, url)
+	if err != nil {
+		return errors.Wrapf(err, "do request to %s", url)
+	}
+	defer resp.Body.Close()
+
+	orcResp := &orcResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(orcResp); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+
+	if orcResp.Code == "ERROR" {
+		return errors.New(orcResp.Message)
+	}
+
+	return nil
+}
+
+func StopReplication(ctx context.Context, api *API, host string, port int32) error {
+	url := fmt.Sprintf("%s/api/stop-replica/%s/%d", api.Host, host, port)
+
+	resp, err := doRequest(ctx, api, url)
+	if err != nil {
+		return errors.Wrapf(err, "do request to %s", url)
+	}
+	defer resp.Body.Close()
+
+	orcResp := &orcResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(orcResp); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+
+	if orcResp.Code == "ERROR" {
+		return errors.New(orcResp.Message)
+	}
+
+	return nil
+}
+
+func StartReplication(ctx context.Context, api *API, host string, port int32) error {
+	url := fmt.Sprintf("%s/api/start-replica/%s/%d", api.Host, host, port)
+
+	resp, err := doRequest(ctx, api, url)
+	if err != nil {
+		return errors.Wrapf(err, "do request to %s", url)
+	}
+	defer resp.Body.Close()
+
+	orcResp := &orcResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(orcResp); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+
+	if orcResp.Code == "ERROR" {
+		return errors.New(orcResp.Message)
+	}
+
+	return nil
+}
+
+func AddPeer(ctx context.Context, api *API, peer string) error {
+	url := fmt.Sprintf("%s/api/raft-add-peer/%s", api.Host, peer)
+
+	resp, err := doRequest(ctx, api, url)
 	if err != nil {
 		return errors.Wrapf(err, "do request to %s", url)
 	}
@@ -133,10 +313,10 @@ func AddPeer(ctx context.Context, apiHost string, peer string) error {
 	return nil
 }
 
-func RemovePeer(ctx context.Context, apiHost string, peer string) error {
-	url := fmt.Sprintf("%s/api/raft-remove-peer/%s", apiHost, peer)
+func RemovePeer(ctx context.Context, api *API, peer string) error {
+	url := fmt.Sprintf("%s/api/raft-remove-peer/%s", api.Host, peer)
 
-	resp, err := doRequest(ctx, url)
+	resp, err := doRequest(ctx, api, url)
 	if err != nil {
 		return errors.Wrapf(err, "do request to %s", url)
 	}
@@ -165,15 +345,35 @@ func RemovePeer(ctx context.Context, apiHost string, peer string) error {
 	return nil
 }
 
-func doRequest(ctx context.Context, url string) (*http.Response, error) {
+func doRequest(ctx context.Context, api *API, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "make request")
 	}
-	resp, err := http.DefaultClient.Do(req)
+	if api.User != "" {
+		req.SetBasicAuth(api.User, api.Password)
+	}
+
+	resp, err := api.client.Do(req)
 	if err != nil {
+		metrics.IncOrchestratorAPIErrors(endpoint(req.URL.Path))
 		return nil, errors.Wrap(err, "do request")
 	}
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		metrics.IncOrchestratorAPIErrors(endpoint(req.URL.Path))
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, errors.New("unauthorized")
+	}
+
 	return resp, nil
 }
+
+// endpoint returns API endpoint name without parameters (e.g. "master" for "/api/master/cluster1.ns")
+func endpoint(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	return parts[0]
+}
@@ -1,18 +1,22 @@
 package orchestrator
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"regexp"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/k8s"
 	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
+	"github.com/percona/percona-server-mysql-operator/pkg/replicator"
 	"github.com/percona/percona-server-mysql-operator/pkg/util"
 	"github.com/pkg/errors"
 )
@@ -28,6 +32,22 @@ const (
 	CredsMountPath   = "/etc/orchestrator/orchestrator-users-secret"
 	tlsVolumeName    = "tls"
 	tlsMountPath     = "/etc/orchestrator/ssl"
+	binVolumeName    = "bin"
+	binMountPath     = "/opt/percona"
+	dataVolumeName   = "datadir"
+	DataMountPath    = "/var/lib/orchestrator"
+
+	// topologyCredsFile is created by the orchestrator image entrypoint
+	// from the orchestrator user password in CredsMountPath
+	topologyCredsFile = "/etc/orchestrator/orc-topology.cnf"
+)
+
+// FailoverHookTokenKey is the key of the failover hook token in the configuration Secret
+const FailoverHookTokenKey = "failover-hook-token"
+
+var (
+	apiPasswordFile = filepath.Join(CredsMountPath, string(apiv1alpha1.UserOrchestratorAPI))
+	tlsCAFile       = filepath.Join(tlsMountPath, "ca.crt")
 )
 
 type Exposer apiv1alpha1.PerconaServerMySQL
@@ -68,7 +88,9 @@ func ServiceName(cr *apiv1alpha1.PerconaServerMySQL) string {
 	return Name(cr)
 }
 
-func ConfigMapName(cr *apiv1alpha1.PerconaServerMySQL) string {
+// ConfigSecretName returns name of the Secret with orchestrator configuration.
+// Configuration is stored in Secret since it contains API credentials.
+func ConfigSecretName(cr *apiv1alpha1.PerconaServerMySQL) string {
 	return Name(cr)
 }
 
@@ -82,7 +104,37 @@ func FQDN(cr *apiv1alpha1.PerconaServerMySQL, idx int) string {
 }
 
 func APIHost(cr *apiv1alpha1.PerconaServerMySQL) string {
-	return fmt.Sprintf("http://%s:%d", FQDN(cr, 0), defaultWebPort)
+	// TODO: DNS suffix
+	return fmt.Sprintf("%s://%s.%s.%s.svc.cluster.local:%d",
+		apiScheme(cr), PodName(cr, 0), ServiceName(cr), cr.Namespace, defaultWebPort)
+}
+
+func apiScheme(cr *apiv1alpha1.PerconaServerMySQL) string {
+	if cr.Spec.Orchestrator.TLSEnabled {
+		return "https"
+	}
+	return "http"
+}
+
+// ClusterAPI returns client of the cluster Orchestrator API
+// with credentials from the internal secret
+func ClusterAPI(ctx context.Context, cl client.Reader, cr *apiv1alpha1.PerconaServerMySQL) (*API, error) {
+	pass, err := k8s.UserPassword(ctx, cl, cr, apiv1alpha1.UserOrchestratorAPI)
+	if err != nil {
+		return nil, errors.Wrap(err, "get orchestrator API password")
+	}
+
+	var caCert []byte
+	if cr.Spec.Orchestrator.TLSEnabled {
+		secret := &corev1.Secret{}
+		nn := types.NamespacedName{Name: cr.Spec.SSLSecretName, Namespace: cr.Namespace}
+		if err := cl.Get(ctx, nn, secret); err != nil {
+			return nil, errors.Wrapf(err, "get secret/%s", nn.Name)
+		}
+		caCert = secret.Data["ca.crt"]
+	}
+
+	return NewAPI(APIHost(cr), string(apiv1alpha1.UserOrchestratorAPI), pass, caCert)
 }
 
 // Labels returns labels of orchestrator
@@ -96,11 +148,14 @@ func MatchLabels(cr *apiv1alpha1.PerconaServerMySQL) map[string]string {
 		cr.Labels())
 }
 
-func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL) *appsv1.StatefulSet {
+func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL, initImage, configHash string) *appsv1.StatefulSet {
 	labels := MatchLabels(cr)
 	spec := cr.OrchestratorSpec()
 	Replicas := spec.Size
 
+	annotations := make(map[string]string)
+	annotations["percona.com/configuration-hash"] = configHash
+
 	return &appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
@@ -112,18 +167,37 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL) *appsv1.StatefulSet {
 			Labels:    labels,
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas:    &Replicas,
-			ServiceName: Name(cr),
+			Replicas:             &Replicas,
+			ServiceName:          Name(cr),
+			VolumeClaimTemplates: volumeClaimTemplates(spec),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
-					NodeSelector:     cr.Spec.Orchestrator.NodeSelector,
-					Tolerations:      cr.Spec.Orchestrator.Tolerations,
+					NodeSelector: cr.Spec.Orchestrator.NodeSelector,
+					Tolerations:  cr.Spec.Orchestrator.Tolerations,
+					InitContainers: []corev1.Container{
+						{
+							Name:            componentName + "-init",
+							Image:           initImage,
+							ImagePullPolicy: spec.ImagePullPolicy,
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      binVolumeName,
+									MountPath: binMountPath,
+								},
+							},
+							Command:                  []string{"/orc-init-entrypoint.sh"},
+							TerminationMessagePath:   "/dev/termination-log",
+							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+							SecurityContext:          spec.ContainerSecurityContext,
+						},
+					},
 					Containers:       containers(cr),
 					Affinity:         spec.GetAffinity(labels),
 					ImagePullSecrets: spec.ImagePullSecrets,
@@ -131,7 +205,13 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL) *appsv1.StatefulSet {
 					RestartPolicy: corev1.RestartPolicyAlways,
 					SchedulerName: "default-scheduler",
 					DNSPolicy:     corev1.DNSClusterFirst,
-					Volumes: []corev1.Volume{
+					Volumes: append(dataVolumes(spec), []corev1.Volume{
+						{
+							Name: binVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
 						{
 							Name: credsVolumeName,
 							VolumeSource: corev1.VolumeSource{
@@ -151,14 +231,12 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL) *appsv1.StatefulSet {
 						{
 							Name: configVolumeName,
 							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: ConfigMapName(cr),
-									},
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: ConfigSecretName(cr),
 								},
 							},
 						},
-					},
+					}...),
 					SecurityContext: spec.PodSecurityContext,
 				},
 			},
@@ -166,6 +244,35 @@ func StatefulSet(cr *apiv1alpha1.PerconaServerMySQL) *appsv1.StatefulSet {
 	}
 }
 
+// volumeClaimTemplates returns PVC for raft and SQLite backend data
+// unless emptyDir or hostPath is used for the data volume
+func volumeClaimTemplates(spec *apiv1alpha1.OrchestratorSpec) []corev1.PersistentVolumeClaim {
+	if spec.VolumeSpec.PersistentVolumeClaim == nil {
+		return nil
+	}
+
+	return []corev1.PersistentVolumeClaim{
+		k8s.PVC(dataVolumeName, spec.VolumeSpec),
+	}
+}
+
+func dataVolumes(spec *apiv1alpha1.OrchestratorSpec) []corev1.Volume {
+	switch {
+	case spec.VolumeSpec.PersistentVolumeClaim != nil:
+		return nil
+	case spec.VolumeSpec.HostPath != nil:
+		return []corev1.Volume{{
+			Name:         dataVolumeName,
+			VolumeSource: corev1.VolumeSource{HostPath: spec.VolumeSpec.HostPath},
+		}}
+	default:
+		return []corev1.Volume{{
+			Name:         dataVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: spec.VolumeSpec.EmptyDir},
+		}}
+	}
+}
+
 func containers(cr *apiv1alpha1.PerconaServerMySQL) []corev1.Container {
 	sidecars := sidecarContainers(cr)
 	containers := make([]corev1.Container, 1, len(sidecars)+1)
@@ -203,15 +310,17 @@ func container(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 				ContainerPort: defaultRaftPort,
 			},
 		},
-		VolumeMounts:             containerMounts(),
+		VolumeMounts: append(containerMounts(), corev1.VolumeMount{
+			Name:      dataVolumeName,
+			MountPath: DataMountPath,
+		}),
 		TerminationMessagePath:   "/dev/termination-log",
 		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
 		SecurityContext:          cr.Spec.Orchestrator.ContainerSecurityContext,
 		LivenessProbe: &corev1.Probe{
 			Handler: corev1.Handler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/api/lb-check",
-					Port: intstr.FromString("web"),
+				Exec: &corev1.ExecAction{
+					Command: probeCommand(cr, "/api/lb-check"),
 				},
 			},
 			InitialDelaySeconds: 10,
@@ -222,9 +331,8 @@ func container(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 		},
 		ReadinessProbe: &corev1.Probe{
 			Handler: corev1.Handler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/api/health",
-					Port: intstr.FromString("web"),
+				Exec: &corev1.ExecAction{
+					Command: probeCommand(cr, "/api/health"),
 				},
 			},
 			InitialDelaySeconds: 30,
@@ -236,30 +344,57 @@ func container(cr *apiv1alpha1.PerconaServerMySQL) corev1.Container {
 	}
 }
 
-func sidecarContainers(cr *apiv1alpha1.PerconaServerMySQL) []corev1.Container {
-	serviceName := mysql.ServiceName(cr)
+// probeCommand returns a command to check the API endpoint. API requires
+// authentication, so HTTP probes can't be used without exposing the password in pod spec.
+func probeCommand(cr *apiv1alpha1.PerconaServerMySQL, path string) []string {
+	return []string{"/bin/sh", "-c", fmt.Sprintf(`curl -fsS -k -o /dev/null -u %s:"$(cat %s)" %s://127.0.0.1:%d%s`,
+		apiv1alpha1.UserOrchestratorAPI, apiPasswordFile, apiScheme(cr), defaultWebPort, path)}
+}
 
+func sidecarContainers(cr *apiv1alpha1.PerconaServerMySQL) []corev1.Container {
 	return []corev1.Container{
 		{
 			Name:            "mysql-monit",
 			Image:           cr.Spec.Orchestrator.Image,
 			ImagePullPolicy: cr.Spec.Orchestrator.ImagePullPolicy,
 			Env: []corev1.EnvVar{
+				{
+					Name: "POD_NAME",
+					ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.name",
+						},
+					},
+				},
+				{
+					Name: "POD_NAMESPACE",
+					ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.namespace",
+						},
+					},
+				},
 				{
 					Name:  "ORC_SERVICE",
-					Value: serviceName,
+					Value: ServiceName(cr),
 				},
 				{
 					Name:  "MYSQL_SERVICE",
-					Value: serviceName,
+					Value: mysql.ServiceName(cr),
+				},
+				{
+					// unready service has records of all MySQL pods, so
+					// instances are forgotten only after their pods are deleted
+					Name:  "MYSQL_UNREADY_SERVICE",
+					Value: mysql.UnreadyServiceName(cr),
 				},
 			},
-			VolumeMounts: containerMounts(),
-			Args: []string{
-				"/usr/bin/peer-list",
-				"-on-change=/usr/bin/add_mysql_nodes.sh",
-				"-service=$(MYSQL_SERVICE)",
-			},
+			VolumeMounts: append(containerMounts(), corev1.VolumeMount{
+				Name:      binVolumeName,
+				MountPath: binMountPath,
+			}),
+			Command:                  []string{filepath.Join(binMountPath, "orc-discovery")},
+			Args:                     discoveryArgs(cr),
 			TerminationMessagePath:   "/dev/termination-log",
 			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
 			SecurityContext:          cr.Spec.Orchestrator.ContainerSecurityContext,
@@ -267,6 +402,21 @@ func sidecarContainers(cr *apiv1alpha1.PerconaServerMySQL) []corev1.Container {
 	}
 }
 
+func discoveryArgs(cr *apiv1alpha1.PerconaServerMySQL) []string {
+	args := []string{
+		"-service=$(MYSQL_UNREADY_SERVICE)",
+		"-host-service=$(MYSQL_SERVICE)",
+		// pod hostname is used to match the server certificate
+		fmt.Sprintf("-orchestrator=%s://$(POD_NAME).$(ORC_SERVICE).$(POD_NAMESPACE):%d", apiScheme(cr), defaultWebPort),
+		fmt.Sprintf("-api-user=%s", apiv1alpha1.UserOrchestratorAPI),
+		fmt.Sprintf("-api-password-file=%s", apiPasswordFile),
+	}
+	if cr.Spec.Orchestrator.TLSEnabled {
+		args = append(args, fmt.Sprintf("-ca-file=%s", tlsCAFile))
+	}
+	return args
+}
+
 func containerMounts() []corev1.VolumeMount {
 	return []corev1.VolumeMount{
 		{
@@ -282,6 +432,11 @@ func containerMounts() []corev1.VolumeMount {
 			MountPath: filepath.Join(CredsMountPath, string(apiv1alpha1.UserOrchestrator)),
 			SubPath:   string(apiv1alpha1.UserOrchestrator),
 		},
+		{
+			Name:      credsVolumeName,
+			MountPath: apiPasswordFile,
+			SubPath:   string(apiv1alpha1.UserOrchestratorAPI),
+		},
 	}
 }
 
@@ -348,17 +503,18 @@ func PodService(cr *apiv1alpha1.PerconaServerMySQL, t corev1.ServiceType, podNam
 	}
 }
 
-func ConfigMap(cr *apiv1alpha1.PerconaServerMySQL, data map[string]string) *corev1.ConfigMap {
-	return &corev1.ConfigMap{
+func ConfigSecret(cr *apiv1alpha1.PerconaServerMySQL, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
-			Kind:       "ConfigMap",
+			Kind:       "Secret",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ConfigMapName(cr),
+			Name:      ConfigSecretName(cr),
 			Namespace: cr.Namespace,
 		},
 		Data: data,
+		Type: corev1.SecretTypeOpaque,
 	}
 }
 
@@ -372,27 +528,125 @@ func RaftNodes(cr *apiv1alpha1.PerconaServerMySQL) []string {
 	return nodes
 }
 
-func orcConfig(cr *apiv1alpha1.PerconaServerMySQL) (string, error) {
-	config := make(map[string]interface{}, 0)
+// failoverHookCommand returns a command to notify the operator about a new primary.
+// Token of the hook is read from the mounted configuration secret.
+func failoverHookCommand(cr *apiv1alpha1.PerconaServerMySQL, hookURL string) string {
+	return fmt.Sprintf(`curl -s -m 10 -X POST -H "Authorization: Bearer $(cat %s)" "%s?namespace=%s&name=%s`+
+		`&failureType={failureType}&failedHost={failedHost}&successorHost={successorHost}&isSuccessful={isSuccessful}"`,
+		filepath.Join(configMountPath, FailoverHookTokenKey),
+		hookURL, cr.Namespace, cr.Name)
+}
+
+// defaultConfig returns configuration options which can be overridden in spec.orchestrator.configuration
+func defaultConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"RecoverMasterClusterFilters":             []string{".*"},
+		"RecoverIntermediateMasterClusterFilters": []string{".*"},
+	}
+}
+
+// managedConfig returns configuration options which are required by the operator.
+// They can't be overridden in spec.orchestrator.configuration.
+func managedConfig(cr *apiv1alpha1.PerconaServerMySQL, apiPassword string) map[string]interface{} {
+	config := map[string]interface{}{
+		"RaftEnabled":                        true,
+		"RaftNodes":                          RaftNodes(cr),
+		"RaftDataDir":                        DataMountPath,
+		"BackendDB":                          "sqlite",
+		"SQLite3DataFile":                    filepath.Join(DataMountPath, "orc.sqlite3"),
+		"MySQLTopologyCredentialsConfigFile": topologyCredsFile,
+		"MySQLTopologySSLCAFile":             tlsCAFile,
+		"MySQLTopologySSLCertFile":           filepath.Join(tlsMountPath, "tls.crt"),
+		"MySQLTopologySSLPrivateKeyFile":     filepath.Join(tlsMountPath, "tls.key"),
+		// instance alias is the pod name
+		"DetectInstanceAliasQuery": "SELECT @@hostname",
+		// cluster alias is <cluster name>.<namespace>, see PerconaServerMySQL.ClusterHint()
+		"DetectClusterAliasQuery": fmt.Sprintf("SELECT '%s'", cr.ClusterHint()),
+		"AuthenticationMethod":    "basic",
+		"HTTPAuthUser":            string(apiv1alpha1.UserOrchestratorAPI),
+		"HTTPAuthPassword":        apiPassword,
+	}
+
+	if cr.MySQLSpec().Heartbeat {
+		config["ReplicationLagQuery"] = replicator.ReplicationLagQuery
+	}
+
+	// sources outside of the cluster aren't a part of the cluster topology
+	var filters []string
+	if rs := cr.Spec.ReplicationSource; rs != nil {
+		for _, h := range rs.Hosts {
+			filters = append(filters, "^"+regexp.QuoteMeta(h.Host)+"$")
+		}
+	}
+	if m := cr.Spec.Migration; m != nil {
+		filters = append(filters, "^"+regexp.QuoteMeta(m.Host)+"$")
+	}
+	if len(filters) > 0 {
+		config["DiscoveryIgnoreMasterHostnameFilters"] = filters
+	}
+
+	if cr.Spec.Orchestrator.TLSEnabled {
+		config["UseSSL"] = true
+		config["SSLCAFile"] = tlsCAFile
+		config["SSLCertFile"] = filepath.Join(tlsMountPath, "tls.crt")
+		config["SSLPrivateKeyFile"] = filepath.Join(tlsMountPath, "tls.key")
+	}
+
+	return config
+}
+
+// hookConfigKeys are options with hooks. Operator hooks are appended to the hooks from spec.orchestrator.configuration.
+var hookConfigKeys = []string{"PostFailoverProcesses", "PostGracefulTakeoverProcesses"}
+
+func orcConfig(cr *apiv1alpha1.PerconaServerMySQL, hookURL, apiPassword string) (string, error) {
+	config := defaultConfig()
+
+	if cr.Spec.Orchestrator.Configuration != "" {
+		userConfig := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(cr.Spec.Orchestrator.Configuration), &userConfig); err != nil {
+			return "", errors.Wrap(err, "parse spec.orchestrator.configuration")
+		}
+
+		for k, v := range userConfig {
+			config[k] = v
+		}
+	}
+
+	for k, v := range managedConfig(cr, apiPassword) {
+		config[k] = v
+	}
+
+	if hookURL != "" {
+		hook := failoverHookCommand(cr, hookURL)
+		for _, k := range hookConfigKeys {
+			hooks, _ := config[k].([]interface{})
+			config[k] = append(hooks, hook)
+		}
+	}
 
-	config["RaftNodes"] = RaftNodes(cr)
 	configJson, err := json.Marshal(config)
 	if err != nil {
-		return "", errors.Wrap(err, "marshal orchestrator raft nodes to json")
+		return "", errors.Wrap(err, "marshal orchestrator config to json")
 	}
 
 	return string(configJson), nil
 }
 
-func ConfigMapData(cr *apiv1alpha1.PerconaServerMySQL) (map[string]string, error) {
-	cmData := make(map[string]string, 0)
+// ConfigData returns orchestrator configuration. If hookURL is not empty,
+// orchestrator notifies the operator about failovers using this URL and
+// authenticates with hookToken. API and UI require basic auth with apiPassword.
+func ConfigData(cr *apiv1alpha1.PerconaServerMySQL, hookURL string, hookToken []byte, apiPassword string) (map[string][]byte, error) {
+	data := make(map[string][]byte, 0)
 
-	config, err := orcConfig(cr)
+	config, err := orcConfig(cr, hookURL, apiPassword)
 	if err != nil {
-		return cmData, errors.Wrap(err, "get raft nodes")
+		return data, errors.Wrap(err, "get orchestrator config")
 	}
 
-	cmData[ConfigFileName] = config
+	data[ConfigFileName] = []byte(config)
+	if hookURL != "" {
+		data[FailoverHookTokenKey] = hookToken
+	}
 
-	return cmData, nil
+	return data, nil
 }
@@ -0,0 +1,126 @@
+package parallel
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// DefaultLimit is the number of instances an action runs on at once
+	DefaultLimit = 5
+
+	// UndoTimeout limits compensating actions, they aren't canceled with ctx
+	UndoTimeout = time.Minute
+)
+
+// Action is run on a single instance
+type Action func(ctx context.Context, instance string) error
+
+// Results are errors of the action by instance, nil if it succeeded
+type Results map[string]error
+
+// Succeeded returns sorted instances the action succeeded on
+func (r Results) Succeeded() []string {
+	return r.filter(func(err error) bool { return err == nil })
+}
+
+// Failed returns sorted instances the action failed on
+func (r Results) Failed() []string {
+	return r.filter(func(err error) bool { return err != nil })
+}
+
+// Err returns errors of all failed instances or nil if there are none
+func (r Results) Err() error {
+	errs := make([]error, 0)
+	for _, instance := range r.Failed() {
+		errs = append(errs, errors.Wrap(r[instance], instance))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r Results) filter(f func(err error) bool) []string {
+	instances := make([]string, 0)
+	for instance, err := range r {
+		if f(err) {
+			instances = append(instances, instance)
+		}
+	}
+	sort.Strings(instances)
+	return instances
+}
+
+// Run runs the action on each instance, at most limit of them at once.
+// Failure on an instance doesn't stop the others. Instances which weren't
+// started before ctx is done fail with the error of ctx.
+func Run(ctx context.Context, limit int, instances []string, action Action) Results {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(Results, len(instances))
+		sem     = make(chan struct{}, limit)
+	)
+
+	setResult := func(instance string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[instance] = err
+	}
+
+	for _, instance := range instances {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			setResult(instance, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(instance string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			setResult(instance, action(ctx, instance))
+		}(instance)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// RunOrUndo runs the action on each instance like Run. If it fails on any
+// of them, undo is run on the instances the action succeeded on, so they
+// aren't left in an intermediate state. The returned error contains errors
+// of the action and of undo.
+func RunOrUndo(ctx context.Context, limit int, instances []string, action, undo Action) (Results, error) {
+	results := Run(ctx, limit, instances, action)
+	err := results.Err()
+	if err == nil {
+		return results, nil
+	}
+
+	if undoErr := Undo(limit, results.Succeeded(), undo).Err(); undoErr != nil {
+		return results, utilerrors.NewAggregate([]error{err, errors.Wrap(undoErr, "undo")})
+	}
+
+	return results, err
+}
+
+// Undo runs the compensating action on each instance like Run. It's run even
+// if the context of the failed action is done, e.g. to restart stopped
+// replication, but no longer than UndoTimeout.
+func Undo(limit int, instances []string, undo Action) Results {
+	ctx, cancel := context.WithTimeout(context.Background(), UndoTimeout)
+	defer cancel()
+
+	return Run(ctx, limit, instances, undo)
+}
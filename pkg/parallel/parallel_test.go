@@ -0,0 +1,230 @@
+package parallel
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// concurrency records the max number of instances the action was running on at once
+type concurrency struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (c *concurrency) action(ctx context.Context, instance string) error {
+	c.mu.Lock()
+	c.running++
+	if c.running > c.max {
+		c.max = c.running
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+
+	return nil
+}
+
+func TestRunLimit(t *testing.T) {
+	instances := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	for _, limit := range []int{1, 3, len(instances)} {
+		c := &concurrency{}
+		results := Run(context.Background(), limit, instances, c.action)
+
+		maxRunning := c.max
+		if maxRunning > limit {
+			t.Errorf("limit %d: %d instances were running at once", limit, maxRunning)
+		}
+		if limit > 1 && maxRunning < 2 {
+			t.Errorf("limit %d: instances weren't running in parallel", limit)
+		}
+		if got := results.Succeeded(); !reflect.DeepEqual(got, instances) {
+			t.Errorf("limit %d: expected all instances to succeed, got %v", limit, got)
+		}
+	}
+}
+
+func TestRunDefaultLimit(t *testing.T) {
+	instances := make([]string, DefaultLimit*2)
+	for i := range instances {
+		instances[i] = string(rune('a' + i))
+	}
+
+	c := &concurrency{}
+	Run(context.Background(), 0, instances, c.action)
+
+	if c.max > DefaultLimit {
+		t.Errorf("%d instances were running at once with default limit %d", c.max, DefaultLimit)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	results := Run(context.Background(), 2, []string{"a", "b", "c"}, func(ctx context.Context, instance string) error {
+		if instance == "b" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if got := results.Succeeded(); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("expected a and c to succeed, got %v", got)
+	}
+	if got := results.Failed(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("expected b to fail, got %v", got)
+	}
+	if err := results.Err(); err == nil || err.Error() != "b: failed" {
+		t.Errorf("expected error of b, got %v", err)
+	}
+
+	if err := (Results{"a": nil}).Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	started := make([]string, 0)
+	results := Run(ctx, 1, []string{"a", "b", "c"}, func(ctx context.Context, instance string) error {
+		mu.Lock()
+		started = append(started, instance)
+		mu.Unlock()
+
+		// the slot is taken until after ctx is done, so the other
+		// instances aren't started
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	if !reflect.DeepEqual(started, []string{"a"}) {
+		t.Errorf("expected only a to be started, got %v", started)
+	}
+	if results["a"] != nil {
+		t.Errorf("expected a to succeed, got %v", results["a"])
+	}
+	for _, instance := range []string{"b", "c"} {
+		if err, ok := results[instance]; !ok || err != context.Canceled {
+			t.Errorf("expected %s to fail with %v, got %v", instance, context.Canceled, err)
+		}
+	}
+}
+
+func TestRunOrUndo(t *testing.T) {
+	var mu sync.Mutex
+	undone := make([]string, 0)
+	undo := func(ctx context.Context, instance string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		undone = append(undone, instance)
+		return nil
+	}
+
+	action := func(ctx context.Context, instance string) error {
+		if instance == "b" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	results, err := RunOrUndo(context.Background(), 2, []string{"a", "b", "c"}, action, undo)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got := results.Failed(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("expected b to fail, got %v", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(undone)
+	if !reflect.DeepEqual(undone, []string{"a", "c"}) {
+		t.Errorf("expected undo on a and c, got %v", undone)
+	}
+}
+
+func TestRunOrUndoSucceeded(t *testing.T) {
+	undone := int32(0)
+	undo := func(ctx context.Context, instance string) error {
+		atomic.AddInt32(&undone, 1)
+		return nil
+	}
+	action := func(ctx context.Context, instance string) error { return nil }
+
+	if _, err := RunOrUndo(context.Background(), 2, []string{"a", "b"}, action, undo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if undone != 0 {
+		t.Errorf("expected no undo, got %d", undone)
+	}
+}
+
+func TestRunOrUndoError(t *testing.T) {
+	action := func(ctx context.Context, instance string) error {
+		if instance == "b" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	undo := func(ctx context.Context, instance string) error {
+		return errors.New("undo failed")
+	}
+
+	_, err := RunOrUndo(context.Background(), 2, []string{"a", "b"}, action, undo)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if expected := "[b: failed, undo: a: undo failed]"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestUndoAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	action := func(ctx context.Context, instance string) error {
+		if instance == "b" {
+			// c isn't started, see TestRunCanceled
+			cancel()
+			time.Sleep(50 * time.Millisecond)
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	var undoCtxErr error
+	var undoDeadline time.Time
+	undo := func(ctx context.Context, instance string) error {
+		undoCtxErr = ctx.Err()
+		undoDeadline, _ = ctx.Deadline()
+		return nil
+	}
+
+	results, err := RunOrUndo(ctx, 1, []string{"a", "b", "c"}, action, undo)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got := results.Succeeded(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected only a to succeed, got %v", got)
+	}
+	if undoCtxErr != nil {
+		t.Errorf("expected undo to run with live context, got %v", undoCtxErr)
+	}
+	if undoDeadline.IsZero() || time.Until(undoDeadline) > UndoTimeout {
+		t.Errorf("expected undo to be limited by %s, deadline %v", UndoTimeout, undoDeadline)
+	}
+}
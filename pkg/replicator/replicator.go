@@ -1,17 +1,34 @@
 package replicator
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/db"
 )
 
 const DefaultChannelName = ""
 
+// SourceChannelName is the channel which replicates from spec.replicationSource
+const SourceChannelName = "source"
+
+// MigrationChannelName is the channel which replicates from spec.migration
+const MigrationChannelName = "migration"
+
+// ReplicationLagQuery returns replication lag in seconds using heartbeat table
+const ReplicationLagQuery = "SELECT TIMESTAMPDIFF(SECOND, ts, UTC_TIMESTAMP()) FROM meta.heartbeat WHERE id = 1"
+
+// HeartbeatEventStatus values, event is replicated as SLAVESIDE_DISABLED
+const (
+	HeartbeatEnabled          = "ENABLED"
+	HeartbeatDisabled         = "DISABLED"
+	HeartbeatSlavesideDisable = "SLAVESIDE_DISABLED"
+)
+
 type ReplicationStatus int8
 
 const (
@@ -21,42 +38,79 @@ const (
 )
 
 type Replicator interface {
-	ChangeReplicationSource(host, replicaPass string, port int32) error
-	StartReplication(host, replicaPass string, port int32) error
-	ReplicationStatus() (ReplicationStatus, string, error)
-	EnableReadonly() error
-	IsReadonly() (bool, error)
-	ReportHost() (string, error)
+	ChangeReplicationSource(ctx context.Context, host, replicaPass string, port int32) error
+	StartReplication(ctx context.Context, host, replicaPass string, port int32) error
+	StopReplication(ctx context.Context) error
+	ReplicationStatus(ctx context.Context) (ReplicationStatus, string, error)
+	ChannelReplicationStatus(ctx context.Context, channel string) (ReplicationStatus, string, error)
+	ChannelExists(ctx context.Context, channel string) (bool, error)
+	ChangeChannelSource(ctx context.Context, channel, host, replicaPass string, port int32) error
+	ChangeChannelExternalSource(ctx context.Context, channel, host, user, pass string, port int32) error
+	ChannelReplicationLag(ctx context.Context, channel string) (int64, error)
+	StartChannelReplication(ctx context.Context, channel string) error
+	ResetChannelReplication(ctx context.Context, channel string) error
+	FailoverSources(ctx context.Context, channel string) ([]FailoverSource, error)
+	AddFailoverSource(ctx context.Context, channel string, src FailoverSource) error
+	DeleteFailoverSource(ctx context.Context, channel string, src FailoverSource) error
+	ReplicationThreads(ctx context.Context) (string, string, error)
+	ReplicationDelay(ctx context.Context) (int32, error)
+	SetReplicationDelay(ctx context.Context, delay int32) error
+	StartReplicationUntilBefore(ctx context.Context, gtids string) error
+	ResetReplication(ctx context.Context) error
+	DisableReplicaStart(ctx context.Context) error
+	IsReplicaStartDisabled(ctx context.Context) (bool, error)
+	PersistReadonly(ctx context.Context, enabled bool) error
+	EnableReadonly(ctx context.Context) error
+	DisableReadonly(ctx context.Context) error
+	IsReadonly(ctx context.Context) (bool, error)
+	IsSuperReadonly(ctx context.Context) (bool, error)
+	EnableSuperReadonly(ctx context.Context) error
+	ReportHost(ctx context.Context) (string, error)
 	Close() error
-	CloneInProgress() (bool, error)
-	NeedsClone(donor string, port int32) (bool, error)
-	Clone(donor, user, pass string, port int32) error
-	IsReplica() (bool, error)
-	DumbQuery() error
-	SetSemiSyncSource(enabled bool) error
-	SetSemiSyncSize(size int) error
+	CloneInProgress(ctx context.Context) (bool, error)
+	IsCloned(ctx context.Context) (bool, error)
+	NeedsClone(ctx context.Context, donor string, port int32) (bool, error)
+	Clone(ctx context.Context, donor, user, pass string, port int32) error
+	IsReplica(ctx context.Context) (bool, error)
+	DumbQuery(ctx context.Context) error
+	SetSemiSyncSource(ctx context.Context, enabled bool) error
+	SetSemiSyncSize(ctx context.Context, size int) error
+	SetSemiSyncWaitPoint(ctx context.Context, point string) error
+	IsSemiSyncReplica(ctx context.Context) (bool, error)
+	SetSemiSyncReplica(ctx context.Context, enabled bool) error
+	SemiSyncClients(ctx context.Context) (int, error)
+	RestartReplicationIOThread(ctx context.Context) error
+	HeartbeatEventStatus(ctx context.Context) (string, error)
+	CreateHeartbeat(ctx context.Context) error
+	EnableHeartbeat(ctx context.Context) error
+	DropHeartbeat(ctx context.Context) error
+	ReplicationLag(ctx context.Context) (int64, error)
+	GTIDExecuted(ctx context.Context) (string, error)
+	IsGTIDSubset(ctx context.Context, subset, set string) (bool, error)
+	GTIDSubtract(ctx context.Context, set, subset string) (string, error)
+}
+
+// FailoverSource is a source of asynchronous connection failover of the replication channel
+type FailoverSource struct {
+	Host   string
+	Port   int32
+	Weight int32
 }
 
 type dbImpl struct{ db *sql.DB }
 
-func NewReplicator(user apiv1alpha1.SystemUser, pass, host string, port int32) (Replicator, error) {
-	connStr := fmt.Sprintf("%s:%s@tcp(%s:%d)/performance_schema?interpolateParams=true",
-		user, pass, host, port)
-	db, err := sql.Open("mysql", connStr)
+func NewReplicator(ctx context.Context, cfg db.Config) (Replicator, error) {
+	conn, err := db.Open(ctx, cfg)
 	if err != nil {
-		return nil, errors.Wrap(err, "connect to MySQL")
-	}
-
-	if err := db.Ping(); err != nil {
-		return nil, errors.Wrap(err, "ping database")
+		return nil, err
 	}
 
-	return &dbImpl{db}, nil
+	return &dbImpl{conn}, nil
 }
 
-func (d *dbImpl) ChangeReplicationSource(host, replicaPass string, port int32) error {
+func (d *dbImpl) ChangeReplicationSource(ctx context.Context, host, replicaPass string, port int32) error {
 	// TODO: Make retries configurable
-	_, err := d.db.Exec(`
+	_, err := d.db.ExecContext(ctx, `
             CHANGE REPLICATION SOURCE TO
                 SOURCE_USER=?,
                 SOURCE_PASSWORD=?,
@@ -75,17 +129,133 @@ func (d *dbImpl) ChangeReplicationSource(host, replicaPass string, port int32) e
 	return nil
 }
 
-func (d *dbImpl) StartReplication(host, replicaPass string, port int32) error {
-	if err := d.ChangeReplicationSource(host, replicaPass, port); err != nil {
+func (d *dbImpl) StartReplication(ctx context.Context, host, replicaPass string, port int32) error {
+	if err := d.ChangeReplicationSource(ctx, host, replicaPass, port); err != nil {
 		return errors.Wrap(err, "change replication source")
 	}
 
-	_, err := d.db.Exec("START REPLICA")
+	_, err := d.db.ExecContext(ctx, "START REPLICA")
 	return errors.Wrap(err, "start replication")
 }
 
-func (d *dbImpl) ReplicationStatus() (ReplicationStatus, string, error) {
-	row := d.db.QueryRow(`
+func (d *dbImpl) ChannelExists(ctx context.Context, channel string) (bool, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM replication_connection_configuration
+        WHERE CHANNEL_NAME = ?
+        `, channel).Scan(&count)
+	return count > 0, errors.Wrapf(err, "check if channel %s exists", channel)
+}
+
+// ChangeChannelSource configures the replication channel with asynchronous connection failover.
+// Sources for failover are added by AddFailoverSource.
+func (d *dbImpl) ChangeChannelSource(ctx context.Context, channel, host, replicaPass string, port int32) error {
+	_, err := d.db.ExecContext(ctx, `
+            CHANGE REPLICATION SOURCE TO
+                SOURCE_USER=?,
+                SOURCE_PASSWORD=?,
+                SOURCE_HOST=?,
+                SOURCE_PORT=?,
+                SOURCE_SSL=1,
+                SOURCE_CONNECTION_AUTO_FAILOVER=1,
+                SOURCE_AUTO_POSITION=1,
+                SOURCE_RETRY_COUNT=3,
+                SOURCE_CONNECT_RETRY=60
+            FOR CHANNEL ?
+        `, apiv1alpha1.UserReplication, replicaPass, host, port, channel)
+	return errors.Wrapf(err, "exec CHANGE REPLICATION SOURCE TO FOR CHANNEL %s", channel)
+}
+
+// ChangeChannelExternalSource configures the replication channel from a server outside
+// of the operator, it's replicated using credentials of the user of that server
+func (d *dbImpl) ChangeChannelExternalSource(ctx context.Context, channel, host, user, pass string, port int32) error {
+	_, err := d.db.ExecContext(ctx, `
+            CHANGE REPLICATION SOURCE TO
+                SOURCE_USER=?,
+                SOURCE_PASSWORD=?,
+                SOURCE_HOST=?,
+                SOURCE_PORT=?,
+                SOURCE_SSL=1,
+                SOURCE_AUTO_POSITION=1,
+                SOURCE_RETRY_COUNT=3,
+                SOURCE_CONNECT_RETRY=60
+            FOR CHANNEL ?
+        `, user, pass, host, port, channel)
+	return errors.Wrapf(err, "exec CHANGE REPLICATION SOURCE TO FOR CHANNEL %s", channel)
+}
+
+// ChannelReplicationLag returns seconds since the original commit of the oldest transaction
+// which is being applied on the channel. It's 0 if the applier is idle.
+func (d *dbImpl) ChannelReplicationLag(ctx context.Context, channel string) (int64, error) {
+	var lag int64
+	err := d.db.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(TIMESTAMPDIFF(SECOND, APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP, NOW(6))), 0)
+        FROM replication_applier_status_by_worker
+        WHERE CHANNEL_NAME = ? AND APPLYING_TRANSACTION <> ''
+        `, channel).Scan(&lag)
+	return lag, errors.Wrapf(err, "select replication lag of channel %s", channel)
+}
+
+func (d *dbImpl) StartChannelReplication(ctx context.Context, channel string) error {
+	_, err := d.db.ExecContext(ctx, "START REPLICA FOR CHANNEL ?", channel)
+	return errors.Wrapf(err, "start replication for channel %s", channel)
+}
+
+// ResetChannelReplication stops replication and removes the replication channel
+func (d *dbImpl) ResetChannelReplication(ctx context.Context, channel string) error {
+	if _, err := d.db.ExecContext(ctx, "STOP REPLICA FOR CHANNEL ?", channel); err != nil {
+		return errors.Wrapf(err, "stop replication for channel %s", channel)
+	}
+
+	_, err := d.db.ExecContext(ctx, "RESET REPLICA ALL FOR CHANNEL ?", channel)
+	return errors.Wrapf(err, "reset replica for channel %s", channel)
+}
+
+func (d *dbImpl) FailoverSources(ctx context.Context, channel string) ([]FailoverSource, error) {
+	rows, err := d.db.QueryContext(ctx, `
+        SELECT HOST, PORT, WEIGHT FROM replication_asynchronous_connection_failover
+        WHERE CHANNEL_NAME = ?
+        `, channel)
+	if err != nil {
+		return nil, errors.Wrap(err, "select failover sources")
+	}
+	defer rows.Close()
+
+	var sources []FailoverSource
+	for rows.Next() {
+		var src FailoverSource
+		if err := rows.Scan(&src.Host, &src.Port, &src.Weight); err != nil {
+			return nil, errors.Wrap(err, "scan rows")
+		}
+		sources = append(sources, src)
+	}
+
+	return sources, errors.Wrap(rows.Err(), "read rows")
+}
+
+func (d *dbImpl) AddFailoverSource(ctx context.Context, channel string, src FailoverSource) error {
+	_, err := d.db.ExecContext(ctx, "SELECT asynchronous_connection_failover_add_source(?, ?, ?, '', ?)",
+		channel, src.Host, src.Port, src.Weight)
+	return errors.Wrapf(err, "add failover source %s:%d", src.Host, src.Port)
+}
+
+func (d *dbImpl) DeleteFailoverSource(ctx context.Context, channel string, src FailoverSource) error {
+	_, err := d.db.ExecContext(ctx, "SELECT asynchronous_connection_failover_delete_source(?, ?, ?, '')",
+		channel, src.Host, src.Port)
+	return errors.Wrapf(err, "delete failover source %s:%d", src.Host, src.Port)
+}
+
+func (d *dbImpl) StopReplication(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "STOP REPLICA")
+	return errors.Wrap(err, "stop replication")
+}
+
+func (d *dbImpl) ReplicationStatus(ctx context.Context) (ReplicationStatus, string, error) {
+	return d.ChannelReplicationStatus(ctx, DefaultChannelName)
+}
+
+func (d *dbImpl) ChannelReplicationStatus(ctx context.Context, channel string) (ReplicationStatus, string, error) {
+	row := d.db.QueryRowContext(ctx, `
         SELECT
 	    connection_status.SERVICE_STATE,
 	    applier_status.SERVICE_STATE,
@@ -96,7 +266,7 @@ func (d *dbImpl) ReplicationStatus() (ReplicationStatus, string, error) {
         JOIN replication_applier_status applier_status
             ON connection_status.channel_name = applier_status.channel_name
         WHERE connection_status.channel_name = ?
-        `, DefaultChannelName)
+        `, channel)
 
 	var ioState, sqlState, host string
 	if err := row.Scan(&ioState, &sqlState, &host); err != nil {
@@ -113,25 +283,151 @@ func (d *dbImpl) ReplicationStatus() (ReplicationStatus, string, error) {
 	return ReplicationStatusNotInitiated, "", nil
 }
 
-func (d *dbImpl) IsReplica() (bool, error) {
-	status, _, err := d.ReplicationStatus()
+// ReplicationThreads returns SERVICE_STATE of receiver (IO) and applier (SQL) threads.
+// Both are empty if replication channel doesn't exist.
+func (d *dbImpl) ReplicationThreads(ctx context.Context) (string, string, error) {
+	row := d.db.QueryRowContext(ctx, `
+        SELECT
+            connection_status.SERVICE_STATE,
+            applier_status.SERVICE_STATE
+        FROM replication_connection_status connection_status
+        JOIN replication_applier_status applier_status
+            ON connection_status.channel_name = applier_status.channel_name
+        WHERE connection_status.channel_name = ?
+        `, DefaultChannelName)
+
+	var ioState, sqlState string
+	if err := row.Scan(&ioState, &sqlState); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", nil
+		}
+		return "", "", errors.Wrap(err, "scan replication threads state")
+	}
+
+	return ioState, sqlState, nil
+}
+
+func (d *dbImpl) ReplicationDelay(ctx context.Context) (int32, error) {
+	var delay int32
+	err := d.db.QueryRowContext(ctx, `
+        SELECT DESIRED_DELAY FROM replication_applier_configuration
+        WHERE CHANNEL_NAME = ?
+        `, DefaultChannelName).Scan(&delay)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return delay, errors.Wrap(err, "select replication delay")
+}
+
+// SetReplicationDelay changes SOURCE_DELAY of the replication channel.
+// SQL thread is stopped to apply the change and started again if it was running.
+func (d *dbImpl) SetReplicationDelay(ctx context.Context, delay int32) error {
+	_, sqlState, err := d.ReplicationThreads(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get replication threads state")
+	}
+
+	if sqlState == "ON" {
+		if _, err := d.db.ExecContext(ctx, "STOP REPLICA SQL_THREAD"); err != nil {
+			return errors.Wrap(err, "stop replica SQL thread")
+		}
+	}
+
+	if _, err := d.db.ExecContext(ctx, "CHANGE REPLICATION SOURCE TO SOURCE_DELAY=?", delay); err != nil {
+		return errors.Wrap(err, "exec CHANGE REPLICATION SOURCE TO")
+	}
+
+	if sqlState == "ON" {
+		_, err := d.db.ExecContext(ctx, "START REPLICA SQL_THREAD")
+		return errors.Wrap(err, "start replica SQL thread")
+	}
+
+	return nil
+}
+
+// StartReplicationUntilBefore resets replication delay and starts replication
+// which stops right before the first transaction of the given GTID set.
+func (d *dbImpl) StartReplicationUntilBefore(ctx context.Context, gtids string) error {
+	if _, err := d.db.ExecContext(ctx, "STOP REPLICA SQL_THREAD"); err != nil {
+		return errors.Wrap(err, "stop replica SQL thread")
+	}
+
+	if _, err := d.db.ExecContext(ctx, "CHANGE REPLICATION SOURCE TO SOURCE_DELAY=0"); err != nil {
+		return errors.Wrap(err, "exec CHANGE REPLICATION SOURCE TO")
+	}
+
+	_, err := d.db.ExecContext(ctx, "START REPLICA UNTIL SQL_BEFORE_GTIDS=?", gtids)
+	return errors.Wrap(err, "start replication until SQL_BEFORE_GTIDS")
+}
+
+// ResetReplication stops replication and removes replication channel
+func (d *dbImpl) ResetReplication(ctx context.Context) error {
+	if err := d.StopReplication(ctx); err != nil {
+		return err
+	}
+
+	_, err := d.db.ExecContext(ctx, "RESET REPLICA ALL")
+	return errors.Wrap(err, "reset replica")
+}
+
+// DisableReplicaStart persists skip_slave_start, replication isn't started after restart
+func (d *dbImpl) DisableReplicaStart(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "SET PERSIST_ONLY skip_slave_start=ON")
+	return errors.Wrap(err, "persist skip_slave_start")
+}
+
+// IsReplicaStartDisabled returns true if skip_slave_start is persisted, even if instance wasn't restarted yet
+func (d *dbImpl) IsReplicaStartDisabled(ctx context.Context) (bool, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM persisted_variables
+        WHERE VARIABLE_NAME = 'skip_slave_start' AND VARIABLE_VALUE = 'ON'
+        `).Scan(&count)
+	return count > 0, errors.Wrap(err, "select persisted skip_slave_start")
+}
+
+// PersistReadonly sets read_only and persists it, so it overrides read_only in the config after restart
+func (d *dbImpl) PersistReadonly(ctx context.Context, enabled bool) error {
+	_, err := d.db.ExecContext(ctx, "SET PERSIST read_only=?", enabled)
+	return errors.Wrap(err, "persist read_only")
+}
+
+func (d *dbImpl) IsReplica(ctx context.Context) (bool, error) {
+	status, _, err := d.ReplicationStatus(ctx)
 	return status == ReplicationStatusActive, errors.Wrap(err, "get replication status")
 }
 
-func (d *dbImpl) EnableReadonly() error {
-	_, err := d.db.Exec("SET GLOBAL READ_ONLY=1")
+func (d *dbImpl) EnableReadonly(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL READ_ONLY=1")
 	return errors.Wrap(err, "set global read_only param to 1")
 }
 
-func (d *dbImpl) IsReadonly() (bool, error) {
+func (d *dbImpl) DisableReadonly(ctx context.Context) error {
+	// disabling read_only disables super_read_only too
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL READ_ONLY=0")
+	return errors.Wrap(err, "set global read_only param to 0")
+}
+
+func (d *dbImpl) IsReadonly(ctx context.Context) (bool, error) {
 	var readonly int
-	err := d.db.QueryRow("select @@read_only").Scan(&readonly)
+	err := d.db.QueryRowContext(ctx, "select @@read_only").Scan(&readonly)
 	return readonly == 1, errors.Wrap(err, "select global read_only param")
 }
 
-func (d *dbImpl) ReportHost() (string, error) {
+func (d *dbImpl) IsSuperReadonly(ctx context.Context) (bool, error) {
+	var superReadonly int
+	err := d.db.QueryRowContext(ctx, "select @@super_read_only").Scan(&superReadonly)
+	return superReadonly == 1, errors.Wrap(err, "select global super_read_only param")
+}
+
+func (d *dbImpl) EnableSuperReadonly(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL SUPER_READ_ONLY=1")
+	return errors.Wrap(err, "set global super_read_only param to 1")
+}
+
+func (d *dbImpl) ReportHost(ctx context.Context) (string, error) {
 	var reportHost string
-	err := d.db.QueryRow("select @@report_host").Scan(&reportHost)
+	err := d.db.QueryRowContext(ctx, "select @@report_host").Scan(&reportHost)
 	return reportHost, errors.Wrap(err, "select report_host param")
 }
 
@@ -139,8 +435,8 @@ func (d *dbImpl) Close() error {
 	return d.db.Close()
 }
 
-func (d *dbImpl) CloneInProgress() (bool, error) {
-	rows, err := d.db.Query("SELECT STATE FROM clone_status")
+func (d *dbImpl) CloneInProgress(ctx context.Context) (bool, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT STATE FROM clone_status")
 	if err != nil {
 		return false, errors.Wrap(err, "fetch clone status")
 	}
@@ -160,8 +456,15 @@ func (d *dbImpl) CloneInProgress() (bool, error) {
 	return false, nil
 }
 
-func (d *dbImpl) NeedsClone(donor string, port int32) (bool, error) {
-	rows, err := d.db.Query("SELECT SOURCE, STATE FROM clone_status")
+// IsCloned returns true if the instance was cloned from any donor
+func (d *dbImpl) IsCloned(ctx context.Context) (bool, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM clone_status WHERE STATE = 'Completed'").Scan(&count)
+	return count > 0, errors.Wrap(err, "fetch clone status")
+}
+
+func (d *dbImpl) NeedsClone(ctx context.Context, donor string, port int32) (bool, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT SOURCE, STATE FROM clone_status")
 	if err != nil {
 		return false, errors.Wrap(err, "fetch clone status")
 	}
@@ -180,13 +483,13 @@ func (d *dbImpl) NeedsClone(donor string, port int32) (bool, error) {
 	return true, nil
 }
 
-func (d *dbImpl) Clone(donor, user, pass string, port int32) error {
-	_, err := d.db.Exec("SET GLOBAL clone_valid_donor_list=?", fmt.Sprintf("%s:%d", donor, port))
+func (d *dbImpl) Clone(ctx context.Context, donor, user, pass string, port int32) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL clone_valid_donor_list=?", fmt.Sprintf("%s:%d", donor, port))
 	if err != nil {
 		return errors.Wrap(err, "set clone_valid_donor_list")
 	}
 
-	_, err = d.db.Exec("CLONE INSTANCE FROM ?@?:? IDENTIFIED BY ?", user, donor, port, pass)
+	_, err = d.db.ExecContext(ctx, "CLONE INSTANCE FROM ?@?:? IDENTIFIED BY ?", user, donor, port, pass)
 	if err != nil {
 		return errors.Wrap(err, "clone instance")
 	}
@@ -194,17 +497,119 @@ func (d *dbImpl) Clone(donor, user, pass string, port int32) error {
 	return nil
 }
 
-func (d *dbImpl) DumbQuery() error {
-	_, err := d.db.Query("SELECT 1")
+func (d *dbImpl) DumbQuery(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "SELECT 1")
 	return errors.Wrap(err, "SELECT 1")
 }
 
-func (d *dbImpl) SetSemiSyncSource(enabled bool) error {
-	_, err := d.db.Exec("SET GLOBAL rpl_semi_sync_master_enabled=?", enabled)
+func (d *dbImpl) SetSemiSyncSource(ctx context.Context, enabled bool) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_enabled=?", enabled)
 	return errors.Wrap(err, "set rpl_semi_sync_master_enabled")
 }
 
-func (d *dbImpl) SetSemiSyncSize(size int) error {
-	_, err := d.db.Exec("SET GLOBAL rpl_semi_sync_master_wait_for_slave_count=?", size)
+func (d *dbImpl) SetSemiSyncSize(ctx context.Context, size int) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_wait_for_slave_count=?", size)
 	return errors.Wrap(err, "set rpl_semi_sync_master_wait_for_slave_count")
 }
+
+func (d *dbImpl) SetSemiSyncWaitPoint(ctx context.Context, point string) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_wait_point=?", point)
+	return errors.Wrap(err, "set rpl_semi_sync_master_wait_point")
+}
+
+func (d *dbImpl) IsSemiSyncReplica(ctx context.Context) (bool, error) {
+	var enabled int
+	err := d.db.QueryRowContext(ctx, "SELECT @@rpl_semi_sync_slave_enabled").Scan(&enabled)
+	return enabled == 1, errors.Wrap(err, "select rpl_semi_sync_slave_enabled")
+}
+
+func (d *dbImpl) SetSemiSyncReplica(ctx context.Context, enabled bool) error {
+	_, err := d.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_slave_enabled=?", enabled)
+	return errors.Wrap(err, "set rpl_semi_sync_slave_enabled")
+}
+
+// SemiSyncClients returns the number of semi-sync replicas connected to the source
+func (d *dbImpl) SemiSyncClients(ctx context.Context) (int, error) {
+	var clients int
+	err := d.db.QueryRowContext(ctx, `
+        SELECT VARIABLE_VALUE FROM global_status
+        WHERE VARIABLE_NAME = 'Rpl_semi_sync_master_clients'
+        `).Scan(&clients)
+	return clients, errors.Wrap(err, "select Rpl_semi_sync_master_clients")
+}
+
+// RestartReplicationIOThread reconnects replica to the source,
+// e.g. to apply rpl_semi_sync_slave_enabled
+func (d *dbImpl) RestartReplicationIOThread(ctx context.Context) error {
+	if _, err := d.db.ExecContext(ctx, "STOP REPLICA IO_THREAD"); err != nil {
+		return errors.Wrap(err, "stop replica IO thread")
+	}
+	_, err := d.db.ExecContext(ctx, "START REPLICA IO_THREAD")
+	return errors.Wrap(err, "start replica IO thread")
+}
+
+// HeartbeatEventStatus returns status of the heartbeat event or empty string if event doesn't exist
+func (d *dbImpl) HeartbeatEventStatus(ctx context.Context) (string, error) {
+	var status string
+	err := d.db.QueryRowContext(ctx, `
+        SELECT STATUS FROM information_schema.EVENTS
+        WHERE EVENT_SCHEMA = 'meta' AND EVENT_NAME = 'heartbeat'
+        `).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return status, errors.Wrap(err, "select heartbeat event status")
+}
+
+// CreateHeartbeat creates heartbeat table and event which updates it every second
+func (d *dbImpl) CreateHeartbeat(ctx context.Context) error {
+	queries := []string{
+		"CREATE DATABASE IF NOT EXISTS meta",
+		"CREATE TABLE IF NOT EXISTS meta.heartbeat (id TINYINT UNSIGNED NOT NULL PRIMARY KEY, ts TIMESTAMP(6) NOT NULL)",
+		`CREATE EVENT IF NOT EXISTS meta.heartbeat ON SCHEDULE EVERY 1 SECOND
+            DO REPLACE INTO meta.heartbeat (id, ts) VALUES (1, UTC_TIMESTAMP(6))`,
+	}
+	for _, q := range queries {
+		if _, err := d.db.ExecContext(ctx, q); err != nil {
+			return errors.Wrapf(err, "exec %s", q)
+		}
+	}
+	return nil
+}
+
+// EnableHeartbeat enables heartbeat event, e.g. on a replica promoted to primary
+func (d *dbImpl) EnableHeartbeat(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "ALTER EVENT meta.heartbeat ENABLE")
+	return errors.Wrap(err, "enable heartbeat event")
+}
+
+func (d *dbImpl) DropHeartbeat(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "DROP EVENT IF EXISTS meta.heartbeat")
+	return errors.Wrap(err, "drop heartbeat event")
+}
+
+func (d *dbImpl) ReplicationLag(ctx context.Context) (int64, error) {
+	var lag int64
+	err := d.db.QueryRowContext(ctx, ReplicationLagQuery).Scan(&lag)
+	return lag, errors.Wrap(err, "select replication lag")
+}
+
+func (d *dbImpl) GTIDExecuted(ctx context.Context) (string, error) {
+	var gtid string
+	err := d.db.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtid)
+	return gtid, errors.Wrap(err, "select gtid_executed")
+}
+
+// IsGTIDSubset returns true if all GTIDs in subset are also in set
+func (d *dbImpl) IsGTIDSubset(ctx context.Context, subset, set string) (bool, error) {
+	var isSubset int
+	err := d.db.QueryRowContext(ctx, "SELECT GTID_SUBSET(?, ?)", subset, set).Scan(&isSubset)
+	return isSubset == 1, errors.Wrap(err, "select GTID_SUBSET")
+}
+
+// GTIDSubtract returns GTIDs from set which are not in subset
+func (d *dbImpl) GTIDSubtract(ctx context.Context, set, subset string) (string, error) {
+	var result string
+	err := d.db.QueryRowContext(ctx, "SELECT GTID_SUBTRACT(?, ?)", set, subset).Scan(&result)
+	return result, errors.Wrap(err, "select GTID_SUBTRACT")
+}
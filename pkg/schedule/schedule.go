@@ -0,0 +1,198 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule is a parsed cron expression of the standard 5 fields: minute,
+// hour, day of month, month and day of week. Times are matched in UTC.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are set if the field starts with "*" or "?". If
+	// both day fields are restricted, the day matches either of them like in cron.
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is Sunday as well as 0
+	dows = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// searchLimit is how far Next looks for a matching time. Every valid
+// schedule matches at least once in 5 years, e.g. "0 0 29 2 *".
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Parse parses a cron expression, e.g. "0 3 * * sun" or "@weekly"
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("expected 5 fields in %q, got %d", spec, len(fields))
+	}
+
+	s := new(Schedule)
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, errors.Wrap(err, "minute")
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, errors.Wrap(err, "hour")
+	}
+	if s.dom, err = parseField(fields[2], doms); err != nil {
+		return nil, errors.Wrap(err, "day of month")
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, errors.Wrap(err, "month")
+	}
+	if s.dow, err = parseField(fields[4], dows); err != nil {
+		return nil, errors.Wrap(err, "day of week")
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[2], "?")
+	s.dowStar = strings.HasPrefix(fields[4], "*") || strings.HasPrefix(fields[4], "?")
+
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, errors.Errorf("%q never matches", spec)
+	}
+
+	return s, nil
+}
+
+// parseField returns bits of the values matched by comma separated list of
+// "*", values, ranges and steps, e.g. "1-5", "*/15" or "mon-fri"
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		rangeExpr, step := expr, uint(1)
+		if i := strings.Index(expr, "/"); i >= 0 {
+			n, err := strconv.ParseUint(expr[i+1:], 10, 8)
+			if err != nil || n == 0 {
+				return 0, errors.Errorf("invalid step in %q", expr)
+			}
+			rangeExpr, step = expr[:i], uint(n)
+		}
+
+		var start, end uint
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			start, end = b.min, b.max
+		case strings.Contains(rangeExpr, "-"):
+			i := strings.Index(rangeExpr, "-")
+			var err error
+			if start, err = parseValue(rangeExpr[:i], b); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(rangeExpr[i+1:], b); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, errors.Errorf("invalid range %q", rangeExpr)
+			}
+		default:
+			v, err := parseValue(rangeExpr, b)
+			if err != nil {
+				return 0, err
+			}
+			start, end = v, v
+			// "5/10" means from 5 to the max value
+			if step > 1 {
+				end = b.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(value string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, errors.Errorf("invalid value %q", value)
+	}
+	if uint(n) < b.min || uint(n) > b.max {
+		return 0, errors.Errorf("%d is out of range %d-%d", n, b.min, b.max)
+	}
+
+	return uint(n), nil
+}
+
+// Next returns the first matching time after t or zero time if there is
+// no match in 5 years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
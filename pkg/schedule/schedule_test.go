@@ -0,0 +1,79 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Monday
+	from := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 1, 16, 10, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * sun", time.Date(2024, 1, 21, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2024, 1, 21, 3, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// restricted day of month and day of week match either of them
+		{"0 0 20 * fri", time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"@Daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := s.Next(from); !got.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNextInOtherLocation(t *testing.T) {
+	s, err := Parse("0 3 * * *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	from := time.Date(2024, 1, 15, 4, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	expected := time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"0 0 30 feb *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
@@ -18,21 +18,47 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
 )
 
 var validityNotAfter = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
 
-func GenerateCertsSecret(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) (*corev1.Secret, error) {
+// DNSNames returns host names the certificate of the cluster is issued for.
+// Instances are connected by names in the MySQL service, the unready service
+// and services of replica pools, and their certificates are verified against
+// these names. Clients of the binding Secret connect by names of the primary
+// and the MySQL services.
+func DNSNames(cr *apiv1alpha1.PerconaServerMySQL) []string {
 	// TODO: DNS suffix
-	hosts := []string{
-		fmt.Sprintf("*.%s-mysql", cr.Name),
-		fmt.Sprintf("*.%s-mysql.%s", cr.Name, cr.Namespace),
-		fmt.Sprintf("*.%s-mysql.%s.svc.cluster.local", cr.Name, cr.Namespace),
-		fmt.Sprintf("*.%s-orchestrator", cr.Name),
-		fmt.Sprintf("*.%s-orchestrator.%s", cr.Name, cr.Namespace),
-		fmt.Sprintf("*.%s-orchestrator.%s.svc.cluster.local", cr.Name, cr.Namespace),
+	services := []string{mysql.ServiceName(cr), mysql.UnreadyServiceName(cr)}
+	for _, pool := range cr.MySQLSpec().ReplicaPools {
+		services = append(services, mysql.ReplicaPoolName(cr, pool.Name))
+	}
+	services = append(services, cr.Name+"-orc")
+
+	hosts := make([]string, 0, len(services)*3+6)
+	for _, svc := range services {
+		hosts = append(hosts,
+			fmt.Sprintf("*.%s", svc),
+			fmt.Sprintf("*.%s.%s", svc, cr.Namespace),
+			fmt.Sprintf("*.%s.%s.svc.cluster.local", svc, cr.Namespace),
+		)
 	}
 
+	for _, svc := range []string{mysql.PrimaryServiceName(cr), mysql.ServiceName(cr)} {
+		hosts = append(hosts,
+			svc,
+			fmt.Sprintf("%s.%s", svc, cr.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", svc, cr.Namespace),
+		)
+	}
+
+	return hosts
+}
+
+func GenerateCertsSecret(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL) (*corev1.Secret, error) {
+	hosts := DNSNames(cr)
+
 	ca, cert, key, err := issueCerts(hosts)
 	if err != nil {
 		return nil, errors.Wrap(err, "issue TLS certificates")
@@ -53,6 +79,51 @@ func GenerateCertsSecret(ctx context.Context, cr *apiv1alpha1.PerconaServerMySQL
 	return secret, nil
 }
 
+// MissingDNSNames returns the host names which the PEM encoded certificate
+// isn't issued for
+func MissingDNSNames(cert []byte, hosts []string) ([]string, error) {
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return nil, errors.New("no PEM data in certificate")
+	}
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse certificate")
+	}
+
+	names := make(map[string]struct{}, len(c.DNSNames))
+	for _, name := range c.DNSNames {
+		names[name] = struct{}{}
+	}
+
+	missing := make([]string, 0)
+	for _, host := range hosts {
+		if _, ok := names[host]; !ok {
+			missing = append(missing, host)
+		}
+	}
+
+	return missing, nil
+}
+
+// AppendCA adds the first certificate of the PEM encoded ca to ca.crt of the
+// TLS secret. Certificates issued by the old CA are trusted until instances
+// are restarted with the new certificate.
+func AppendCA(secret *corev1.Secret, ca []byte) error {
+	block, _ := pem.Decode(ca)
+	if block == nil {
+		return errors.New("no PEM data in CA certificate")
+	}
+
+	bundle := bytes.NewBuffer(secret.Data["ca.crt"])
+	if err := pem.Encode(bundle, block); err != nil {
+		return errors.Wrap(err, "encode CA certificate")
+	}
+	secret.Data["ca.crt"] = bundle.Bytes()
+
+	return nil
+}
+
 // issueCerts returns CA certificate, TLS certificate and TLS private key
 func issueCerts(hosts []string) (caCert, tlsCert, tlsKey []byte, err error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -174,30 +245,25 @@ var secretUsers = [...]apiv1alpha1.SystemUser{
 	apiv1alpha1.UserOperator,
 	apiv1alpha1.UserReplication,
 	apiv1alpha1.UserOrchestrator,
+	apiv1alpha1.UserOrchestratorAPI,
 }
 
-func GeneratePasswordsSecret(name, namespace string) (*corev1.Secret, error) {
+// GeneratePasswords generates passwords of system users
+func GeneratePasswords() (map[string][]byte, error) {
 	data := make(map[string][]byte)
 	for _, user := range secretUsers {
-		pass, err := generatePass()
+		pass, err := GeneratePass()
 		if err != nil {
 			return nil, errors.Wrapf(err, "create %s user password", user)
 		}
 		data[string(user)] = pass
 	}
 
-	return &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-		},
-		Data: data,
-		Type: corev1.SecretTypeOpaque,
-	}, nil
+	return data, nil
 }
 
-// generatePass generates a random password
-func generatePass() ([]byte, error) {
+// GeneratePass generates a random password
+func GeneratePass() ([]byte, error) {
 	mrand.Seed(time.Now().UnixNano())
 	ln := mrand.Intn(passwordMaxLen-passwordMinLen) + passwordMinLen
 	b := make([]byte, ln)
@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
+)
+
+func cluster(pools ...string) *apiv1alpha1.PerconaServerMySQL {
+	cr := &apiv1alpha1.PerconaServerMySQL{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "ns"}}
+	for _, pool := range pools {
+		cr.Spec.MySQL.ReplicaPools = append(cr.Spec.MySQL.ReplicaPools, apiv1alpha1.ReplicaPoolSpec{Name: pool})
+	}
+	return cr
+}
+
+func TestDNSNames(t *testing.T) {
+	names := DNSNames(cluster("delayed"))
+
+	for _, name := range []string{
+		"*.cluster1-mysql",
+		"*.cluster1-mysql.ns",
+		"*.cluster1-mysql.ns.svc.cluster.local",
+		"*.cluster1-mysql-unready.ns",
+		"*.cluster1-mysql-delayed.ns",
+		"*.cluster1-orc.ns",
+	} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("expected %s in %v", name, names)
+		}
+	}
+}
+
+func TestCertificateOfBindingHosts(t *testing.T) {
+	cr := cluster()
+	s, err := GenerateCertsSecret(context.Background(), cr)
+	if err != nil {
+		t.Fatalf("generate certificates: %v", err)
+	}
+
+	block, _ := pem.Decode(s.Data["tls.crt"])
+	if block == nil {
+		t.Fatal("no PEM data in certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	binding := mysql.BindingSecret(cr, "cluster1-binding", "app", "pass", "", s.Data["ca.crt"])
+	for _, key := range []string{"host", "read-only-host"} {
+		host := string(binding.Data[key])
+		if err := cert.VerifyHostname(host); err != nil {
+			t.Errorf("expected certificate to be valid for %s %s: %v", key, host, err)
+		}
+		if err := cert.VerifyHostname(host + ".svc.cluster.local"); err != nil {
+			t.Errorf("expected certificate to be valid for FQDN of %s %s: %v", key, host, err)
+		}
+	}
+}
+
+func TestMissingDNSNames(t *testing.T) {
+	s, err := GenerateCertsSecret(context.Background(), cluster())
+	if err != nil {
+		t.Fatalf("generate certificates: %v", err)
+	}
+
+	missing, err := MissingDNSNames(s.Data["tls.crt"], DNSNames(cluster()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("expected no missing names, got %v", missing)
+	}
+
+	missing, err = MissingDNSNames(s.Data["tls.crt"], DNSNames(cluster("delayed")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"*.cluster1-mysql-delayed",
+		"*.cluster1-mysql-delayed.ns",
+		"*.cluster1-mysql-delayed.ns.svc.cluster.local",
+	}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("expected missing %v, got %v", expected, missing)
+	}
+
+	if _, err := MissingDNSNames([]byte("not a certificate"), DNSNames(cluster())); err == nil {
+		t.Error("expected error for invalid certificate")
+	}
+}
+
+func TestAppendCA(t *testing.T) {
+	previous, err := GenerateCertsSecret(context.Background(), cluster())
+	if err != nil {
+		t.Fatalf("generate certificates: %v", err)
+	}
+	renewed, err := GenerateCertsSecret(context.Background(), cluster("delayed"))
+	if err != nil {
+		t.Fatalf("generate certificates: %v", err)
+	}
+	ca := renewed.Data["ca.crt"]
+
+	if err := AppendCA(renewed, previous.Data["ca.crt"]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.HasPrefix(renewed.Data["ca.crt"], ca) {
+		t.Error("expected the new CA to be first")
+	}
+	if !bytes.HasSuffix(renewed.Data["ca.crt"], previous.Data["ca.crt"]) {
+		t.Error("expected the previous CA to be kept")
+	}
+
+	blocks := 0
+	for rest := renewed.Data["ca.crt"]; ; blocks++ {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+	}
+	if blocks != 2 {
+		t.Errorf("expected 2 CA certificates, got %d", blocks)
+	}
+
+	if err := AppendCA(renewed, []byte("not a certificate")); err == nil {
+		t.Error("expected error for invalid CA")
+	}
+}
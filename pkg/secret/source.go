@@ -0,0 +1,104 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+// Source is a store of system user passwords, keys are names of system users
+type Source interface {
+	// Passwords returns nil if the store has no passwords
+	Passwords(ctx context.Context) (map[string][]byte, error)
+	SetPasswords(ctx context.Context, passwords map[string][]byte) error
+}
+
+// KubernetesSource stores passwords in the Secret spec.secretsName of the cluster
+type KubernetesSource struct {
+	cl     client.Client
+	scheme *runtime.Scheme
+	cr     *apiv1alpha1.PerconaServerMySQL
+}
+
+func NewKubernetesSource(cl client.Client, scheme *runtime.Scheme, cr *apiv1alpha1.PerconaServerMySQL) *KubernetesSource {
+	return &KubernetesSource{cl: cl, scheme: scheme, cr: cr}
+}
+
+func (s *KubernetesSource) Passwords(ctx context.Context) (map[string][]byte, error) {
+	secret, err := s.get(ctx)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+
+	if secret.Data == nil {
+		return make(map[string][]byte), nil
+	}
+
+	return secret.Data, nil
+}
+
+// SetPasswords creates the Secret owned by the cluster or merges passwords into
+// data of the existing one. Users which are not in passwords are kept, e.g. if
+// they are not stored in the secrets provider yet.
+func (s *KubernetesSource) SetPasswords(ctx context.Context, passwords map[string][]byte) error {
+	secret, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if secret == nil {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.cr.Spec.SecretsName,
+				Namespace: s.cr.Namespace,
+			},
+			Data: passwords,
+			Type: corev1.SecretTypeOpaque,
+		}
+		if err := controllerutil.SetControllerReference(s.cr, secret, s.scheme); err != nil {
+			return errors.Wrapf(err, "set controller reference to Secret/%s", secret.Name)
+		}
+
+		return errors.Wrapf(s.cl.Create(ctx, secret), "create Secret/%s", secret.Name)
+	}
+
+	changed := false
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte, len(passwords))
+	}
+	for user, pass := range passwords {
+		if bytes.Equal(secret.Data[user], pass) {
+			continue
+		}
+		secret.Data[user] = pass
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	return errors.Wrapf(s.cl.Update(ctx, secret), "update Secret/%s", secret.Name)
+}
+
+func (s *KubernetesSource) get(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	nn := types.NamespacedName{Name: s.cr.Spec.SecretsName, Namespace: s.cr.Namespace}
+	if err := s.cl.Get(ctx, nn, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "get Secret/%s", nn.Name)
+	}
+
+	return secret, nil
+}
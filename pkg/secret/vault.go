@@ -0,0 +1,261 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/util"
+)
+
+// serviceAccountTokenFile is the token of the operator used to log in to Vault
+var serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// loginRenewBefore is how long before expiration the token of Kubernetes
+// auth is replaced with a new one
+const loginRenewBefore = time.Minute
+
+// VaultSource stores passwords in a secret of KV version 2 secrets engine of HashiCorp Vault
+type VaultSource struct {
+	address   string
+	mountPath string
+	path      string
+	token     string
+
+	auth  *apiv1alpha1.VaultKubernetesAuthSpec
+	login *VaultLogin
+
+	client *http.Client
+}
+
+// VaultLogin is the token issued by Kubernetes auth method. It's shared by
+// clients of the same cluster and reused until it expires or Vault denies it,
+// so every refresh of passwords doesn't create a new token.
+type VaultLogin struct {
+	mu sync.Mutex
+	// issuer is the address, the auth mount path and the role of the token
+	issuer  string
+	token   string
+	expires time.Time
+}
+
+// NewVaultSource returns a client of the secret. If token is empty, it logs in
+// with Kubernetes auth method using the service account token of the operator,
+// the token of login is reused if it's still valid. Certificate of Vault is
+// verified with caCert if it's not empty, otherwise system root CAs are used.
+func NewVaultSource(ctx context.Context, spec *apiv1alpha1.VaultSpec, token string, caCert []byte, login *VaultLogin) (*VaultSource, error) {
+	client, err := util.HTTPClient(caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &VaultSource{
+		address:   strings.TrimSuffix(spec.Address, "/"),
+		mountPath: strings.Trim(spec.MountPath, "/"),
+		path:      strings.Trim(spec.Path, "/"),
+		token:     token,
+		client:    client,
+	}
+
+	if v.token != "" {
+		return v, nil
+	}
+
+	if spec.KubernetesAuth == nil {
+		return nil, errors.New("token or Kubernetes auth is required")
+	}
+	if login == nil {
+		login = new(VaultLogin)
+	}
+	v.auth = spec.KubernetesAuth
+	v.login = login
+
+	if err := v.authenticate(ctx); err != nil {
+		return nil, errors.Wrap(err, "login with Kubernetes auth")
+	}
+
+	return v, nil
+}
+
+// authenticate sets the token of login if it's valid, otherwise it logs in
+// with Kubernetes auth method
+func (v *VaultSource) authenticate(ctx context.Context) error {
+	mountPath := strings.Trim(v.auth.MountPath, "/")
+	issuer := fmt.Sprintf("%s/%s/%s", v.address, mountPath, v.auth.Role)
+
+	v.login.mu.Lock()
+	defer v.login.mu.Unlock()
+
+	if v.login.issuer == issuer && v.login.token != "" &&
+		(v.login.expires.IsZero() || time.Until(v.login.expires) > loginRenewBefore) {
+		v.token = v.login.token
+		return nil
+	}
+
+	jwt, err := ioutil.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return errors.Wrap(err, "read service account token")
+	}
+
+	req := struct {
+		Role string `json:"role"`
+		JWT  string `json:"jwt"`
+	}{v.auth.Role, string(jwt)}
+	resp := struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}{}
+
+	v.token = ""
+	if _, err := v.do(ctx, http.MethodPost, fmt.Sprintf("/v1/auth/%s/login", mountPath), req, &resp); err != nil {
+		return err
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("empty token")
+	}
+
+	v.token = resp.Auth.ClientToken
+	v.login.issuer = issuer
+	v.login.token = resp.Auth.ClientToken
+	v.login.expires = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		v.login.expires = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	}
+
+	return nil
+}
+
+// invalidate drops the token of login if it's the token of the client
+func (v *VaultSource) invalidate() {
+	v.login.mu.Lock()
+	defer v.login.mu.Unlock()
+
+	if v.login.token == v.token {
+		v.login.token = ""
+	}
+}
+
+// Passwords returns data of the latest version of the secret or nil if the
+// secret doesn't exist or the latest version is deleted
+func (v *VaultSource) Passwords(ctx context.Context) (map[string][]byte, error) {
+	resp := struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}{}
+
+	code, err := v.request(ctx, http.MethodGet, v.dataURL(), nil, &resp)
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read secret %s/%s", v.mountPath, v.path)
+	}
+	if resp.Data.Data == nil {
+		return nil, nil
+	}
+
+	passwords := make(map[string][]byte, len(resp.Data.Data))
+	for user, pass := range resp.Data.Data {
+		passwords[user] = []byte(pass)
+	}
+
+	return passwords, nil
+}
+
+// SetPasswords writes a new version of the secret
+func (v *VaultSource) SetPasswords(ctx context.Context, passwords map[string][]byte) error {
+	data := make(map[string]string, len(passwords))
+	for user, pass := range passwords {
+		data[user] = string(pass)
+	}
+
+	req := struct {
+		Data map[string]string `json:"data"`
+	}{data}
+
+	_, err := v.request(ctx, http.MethodPost, v.dataURL(), req, nil)
+	return errors.Wrapf(err, "write secret %s/%s", v.mountPath, v.path)
+}
+
+func (v *VaultSource) dataURL() string {
+	return fmt.Sprintf("/v1/%s/data/%s", v.mountPath, v.path)
+}
+
+// request sends the request with do. If the token of Kubernetes auth is
+// denied, e.g. it was revoked, the client logs in again and retries once.
+func (v *VaultSource) request(ctx context.Context, method, path string, in, out interface{}) (int, error) {
+	code, err := v.do(ctx, method, path, in, out)
+	if code != http.StatusForbidden || v.login == nil {
+		return code, err
+	}
+
+	v.invalidate()
+	if err := v.authenticate(ctx); err != nil {
+		return code, errors.Wrap(err, "login with Kubernetes auth")
+	}
+
+	return v.do(ctx, method, path, in, out)
+}
+
+// do sends the request and decodes the response into out. It returns the
+// status code of the response and an error if the status is not successful.
+func (v *VaultSource) do(ctx context.Context, method, path string, in, out interface{}) (int, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return 0, errors.Wrap(err, "marshal request")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, v.address+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "make request")
+	}
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, errors.Wrap(err, "read response body")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		vaultErr := struct {
+			Errors []string `json:"errors"`
+		}{}
+		if err := json.Unmarshal(respBody, &vaultErr); err == nil && len(vaultErr.Errors) > 0 {
+			return resp.StatusCode, errors.Errorf("%s: %s", resp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, errors.Wrap(json.Unmarshal(respBody, out), "unmarshal response")
+}
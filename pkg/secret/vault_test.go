@@ -0,0 +1,166 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+// fakeVault issues a new token on every login of Kubernetes auth and
+// accepts only tokens which are not revoked
+type fakeVault struct {
+	mu            sync.Mutex
+	logins        int
+	leaseDuration int
+	valid         map[string]bool
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login":
+		f.logins++
+		token := fmt.Sprintf("token-%d", f.logins)
+		f.valid[token] = true
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": f.leaseDuration},
+		})
+	case "/v1/secret/data/cluster1":
+		if !f.valid[r.Header.Get("X-Vault-Token")] {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"root": "pass"}}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeVault) revokeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.valid = make(map[string]bool)
+}
+
+func newFakeVault(t *testing.T, leaseDuration int) (*fakeVault, *apiv1alpha1.VaultSpec) {
+	t.Helper()
+
+	jwt := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(jwt, []byte("jwt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	orig := serviceAccountTokenFile
+	serviceAccountTokenFile = jwt
+	t.Cleanup(func() { serviceAccountTokenFile = orig })
+
+	f := &fakeVault{leaseDuration: leaseDuration, valid: make(map[string]bool)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	return f, &apiv1alpha1.VaultSpec{
+		Address:        srv.URL,
+		MountPath:      "secret",
+		Path:           "cluster1",
+		KubernetesAuth: &apiv1alpha1.VaultKubernetesAuthSpec{Role: "operator", MountPath: "kubernetes"},
+	}
+}
+
+func readPasswords(t *testing.T, spec *apiv1alpha1.VaultSpec, login *VaultLogin) {
+	t.Helper()
+
+	v, err := NewVaultSource(context.Background(), spec, "", nil, login)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	passwords, err := v.Passwords(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(passwords["root"]) != "pass" {
+		t.Errorf("expected password of root, got %v", passwords)
+	}
+}
+
+func TestVaultLoginIsReused(t *testing.T) {
+	f, spec := newFakeVault(t, 3600)
+	login := new(VaultLogin)
+
+	for i := 0; i < 3; i++ {
+		readPasswords(t, spec, login)
+	}
+	if f.logins != 1 {
+		t.Errorf("expected 1 login, got %d", f.logins)
+	}
+
+	// token of another role isn't reused
+	spec.KubernetesAuth.Role = "other"
+	readPasswords(t, spec, login)
+	if f.logins != 2 {
+		t.Errorf("expected login with another role, got %d logins", f.logins)
+	}
+}
+
+func TestVaultLoginAfterExpiration(t *testing.T) {
+	f, spec := newFakeVault(t, int(loginRenewBefore.Seconds())/2)
+	login := new(VaultLogin)
+
+	readPasswords(t, spec, login)
+	readPasswords(t, spec, login)
+	if f.logins != 2 {
+		t.Errorf("expected login for each client, got %d logins", f.logins)
+	}
+}
+
+func TestVaultLoginAfterDenied(t *testing.T) {
+	f, spec := newFakeVault(t, 3600)
+	login := new(VaultLogin)
+
+	v, err := NewVaultSource(context.Background(), spec, "", nil, login)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.revokeAll()
+	passwords, err := v.Passwords(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(passwords["root"]) != "pass" {
+		t.Errorf("expected password of root, got %v", passwords)
+	}
+	if f.logins != 2 {
+		t.Errorf("expected login after the token was denied, got %d logins", f.logins)
+	}
+
+	// the new token is reused
+	readPasswords(t, spec, login)
+	if f.logins != 2 {
+		t.Errorf("expected the new token to be reused, got %d logins", f.logins)
+	}
+}
+
+func TestVaultStaticTokenDenied(t *testing.T) {
+	f, spec := newFakeVault(t, 3600)
+
+	v, err := NewVaultSource(context.Background(), spec, "revoked", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := v.Passwords(context.Background()); err == nil {
+		t.Error("expected error for denied token")
+	}
+	if f.logins != 0 {
+		t.Errorf("expected no login with static token, got %d", f.logins)
+	}
+}
@@ -0,0 +1,333 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
+)
+
+// CanonicalGrants are the grants of a system user on its hosts
+type CanonicalGrants struct {
+	Hosts  []string
+	Grants []apiv1alpha1.UserGrant
+}
+
+func globalGrant(withGrantOption bool, privileges ...apiv1alpha1.Privilege) apiv1alpha1.UserGrant {
+	return apiv1alpha1.UserGrant{Privileges: privileges, Database: "*", Table: "*", WithGrantOption: withGrantOption}
+}
+
+func selectGrant(database, table string) apiv1alpha1.UserGrant {
+	return apiv1alpha1.UserGrant{Privileges: []apiv1alpha1.Privilege{"SELECT"}, Database: database, Table: table}
+}
+
+// SystemUserGrants are the grants of system users created by ps-entrypoint.sh.
+// Root is not listed, it keeps all privileges.
+//
+// Operator has the privileges it uses to manage replication, clone, heartbeat,
+// users and databases. It also holds the privileges of the other system users
+// and the data privileges with grant option, it can't grant privileges it doesn't have.
+var SystemUserGrants = map[apiv1alpha1.SystemUser]CanonicalGrants{
+	apiv1alpha1.UserOperator: {
+		Hosts: []string{"%"},
+		Grants: []apiv1alpha1.UserGrant{
+			globalGrant(true,
+				"SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "DROP", "RELOAD", "PROCESS",
+				"REFERENCES", "INDEX", "ALTER", "SHOW DATABASES", "SUPER", "CREATE TEMPORARY TABLES",
+				"LOCK TABLES", "EXECUTE", "REPLICATION SLAVE", "REPLICATION CLIENT", "CREATE VIEW",
+				"SHOW VIEW", "CREATE ROUTINE", "ALTER ROUTINE", "CREATE USER", "EVENT", "TRIGGER",
+				"BACKUP_ADMIN", "CLONE_ADMIN", "PERSIST_RO_VARIABLES_ADMIN", "REPLICATION_SLAVE_ADMIN",
+				"SERVICE_CONNECTION_ADMIN", "SYSTEM_USER", "SYSTEM_VARIABLES_ADMIN"),
+		},
+	},
+	apiv1alpha1.UserXtraBackup: {
+		Hosts: []string{"localhost"},
+		Grants: []apiv1alpha1.UserGrant{
+			globalGrant(false, "SYSTEM_USER", "BACKUP_ADMIN", "PROCESS", "RELOAD", "LOCK TABLES", "REPLICATION CLIENT"),
+			selectGrant("performance_schema", "log_status"),
+			selectGrant("performance_schema", "keyring_component_status"),
+		},
+	},
+	apiv1alpha1.UserMonitor: {
+		Hosts: []string{"%"},
+		Grants: []apiv1alpha1.UserGrant{
+			globalGrant(false, "SYSTEM_USER", "SELECT", "PROCESS", "SUPER", "REPLICATION CLIENT", "RELOAD",
+				"BACKUP_ADMIN", "SERVICE_CONNECTION_ADMIN"),
+			selectGrant("performance_schema", "*"),
+		},
+	},
+	apiv1alpha1.UserClusterCheck: {
+		Hosts: []string{"localhost"},
+		Grants: []apiv1alpha1.UserGrant{
+			globalGrant(false, "SYSTEM_USER", "PROCESS"),
+		},
+	},
+	apiv1alpha1.UserReplication: {
+		Hosts: []string{"%"},
+		Grants: []apiv1alpha1.UserGrant{
+			globalGrant(false, "SYSTEM_USER", "REPLICATION SLAVE"),
+		},
+	},
+	apiv1alpha1.UserOrchestrator: {
+		Hosts: []string{"%"},
+		Grants: []apiv1alpha1.UserGrant{
+			globalGrant(false, "SYSTEM_USER", "SUPER", "PROCESS", "REPLICATION SLAVE", "REPLICATION CLIENT", "RELOAD"),
+			selectGrant("mysql", "slave_master_info"),
+			selectGrant("meta", "*"),
+		},
+	},
+}
+
+// showGrantsRegexp matches privilege grants in the output of SHOW GRANTS.
+// Role grants and grants on routines don't match.
+var showGrantsRegexp = regexp.MustCompile("^GRANT (.+) ON ((?:\\*|`(?:[^`]|``)+`)\\.(?:\\*|`(?:[^`]|``)+`)) TO .+?( WITH GRANT OPTION)?$")
+
+// grantSet is a set of privileges on a database object
+type grantSet struct {
+	database        string
+	table           string
+	privileges      map[string]struct{}
+	withGrantOption bool
+}
+
+func (g *grantSet) userGrant(privileges []string, withGrantOption bool) apiv1alpha1.UserGrant {
+	grant := apiv1alpha1.UserGrant{Database: g.database, Table: g.table, WithGrantOption: withGrantOption}
+	for _, p := range privileges {
+		grant.Privileges = append(grant.Privileges, apiv1alpha1.Privilege(p))
+	}
+	return grant
+}
+
+// ReconcileGrants makes grants of the user on its hosts equal to the given grants.
+// Missing privileges are granted before extra ones are revoked, so the operator
+// keeps its privileges while it fixes its own grants. It returns the fixes,
+// nothing is done if the user doesn't exist.
+func (d *dbImpl) ReconcileGrants(ctx context.Context, user mysql.User, grants []apiv1alpha1.UserGrant) ([]string, error) {
+	desired := grantSets(grants)
+
+	fixes := make([]string, 0)
+	for _, host := range user.Hosts {
+		current, err := d.showGrants(ctx, user.Username, host)
+		if err != nil {
+			return fixes, err
+		}
+		if current == nil {
+			continue
+		}
+
+		u := mysql.User{Username: user.Username, Hosts: []string{host}}
+		account := fmt.Sprintf("%s@%s", user.Username, host)
+
+		for _, fix := range grantFixes(desired, current) {
+			if fix.revoke {
+				err = d.Revoke(ctx, u, fix.grant)
+			} else {
+				err = d.Grant(ctx, u, fix.grant)
+			}
+			if err != nil {
+				return fixes, err
+			}
+			fixes = append(fixes, fix.describe(account))
+		}
+	}
+
+	return fixes, nil
+}
+
+// grantFix is a GRANT or REVOKE statement which fixes grants of an account
+type grantFix struct {
+	revoke bool
+	grant  apiv1alpha1.UserGrant
+	// changed are the granted or revoked privileges including GRANT OPTION
+	changed []string
+}
+
+func (f grantFix) describe(account string) string {
+	if f.revoke {
+		return fmt.Sprintf("revoked %s on %s from %s", strings.Join(f.changed, ", "), grantObject(f.grant), account)
+	}
+	return fmt.Sprintf("granted %s on %s to %s", strings.Join(f.changed, ", "), grantObject(f.grant), account)
+}
+
+// grantSets groups privileges of the grants by grant object
+func grantSets(grants []apiv1alpha1.UserGrant) map[string]*grantSet {
+	sets := make(map[string]*grantSet)
+	for _, grant := range grants {
+		key := grantObject(grant)
+		gs, ok := sets[key]
+		if !ok {
+			gs = &grantSet{database: grant.Database, table: grant.Table, privileges: make(map[string]struct{})}
+			sets[key] = gs
+		}
+		for _, p := range grant.Privileges {
+			gs.privileges[strings.ToUpper(string(p))] = struct{}{}
+		}
+		gs.withGrantOption = gs.withGrantOption || grant.WithGrantOption
+	}
+	return sets
+}
+
+// grantFixes returns statements which make the current grants of an account
+// equal to the desired ones. All grants come before revokes.
+func grantFixes(desired, current map[string]*grantSet) []grantFix {
+	fixes := make([]grantFix, 0)
+
+	for _, key := range sortedKeys(desired) {
+		want := desired[key]
+		have, ok := current[key]
+		if !ok {
+			have = &grantSet{privileges: make(map[string]struct{})}
+		}
+
+		missing := privilegeDiff(want.privileges, have.privileges)
+		if want.withGrantOption && !have.withGrantOption {
+			// GRANT OPTION is given to the privileges of the statement
+			missing = privilegeDiff(want.privileges, nil)
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		changed := missing
+		if want.withGrantOption && !have.withGrantOption {
+			changed = append(changed, "GRANT OPTION")
+		}
+		fixes = append(fixes, grantFix{grant: want.userGrant(missing, want.withGrantOption), changed: changed})
+	}
+
+	for _, key := range sortedKeys(current) {
+		have := current[key]
+		want, ok := desired[key]
+		if !ok {
+			want = &grantSet{privileges: make(map[string]struct{})}
+		}
+
+		extra := privilegeDiff(have.privileges, want.privileges)
+		revokeGrantOption := have.withGrantOption && !want.withGrantOption
+		if len(extra) == 0 && !revokeGrantOption {
+			continue
+		}
+
+		grant := have.userGrant(extra, revokeGrantOption)
+		changed := extra
+		if len(extra) == 0 {
+			// Revoke appends GRANT OPTION to the privileges
+			grant.Privileges = []apiv1alpha1.Privilege{"USAGE"}
+		}
+		if revokeGrantOption {
+			changed = append(changed, "GRANT OPTION")
+		}
+		fixes = append(fixes, grantFix{revoke: true, grant: grant, changed: changed})
+	}
+
+	return fixes
+}
+
+// showGrants returns grants of the account by grant object. It returns nil if
+// the account doesn't exist. Column privileges are not returned.
+func (d *dbImpl) showGrants(ctx context.Context, user apiv1alpha1.SystemUser, host string) (map[string]*grantSet, error) {
+	rows, err := d.db.QueryContext(ctx, "SHOW GRANTS FOR ?@?", user, host)
+	var mErr *mysqldriver.MySQLError
+	if errors.As(err, &mErr) && mErr.Number == errNoSuchGrant {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "show grants for %s@%s", user, host)
+	}
+	defer rows.Close()
+
+	lines := make([]string, 0)
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, errors.Wrap(err, "scan rows")
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows")
+	}
+
+	return parseGrants(lines), nil
+}
+
+// parseGrants returns privileges by grant object from the lines of SHOW GRANTS.
+// Role grants, grants on routines and column privileges are skipped.
+func parseGrants(lines []string) map[string]*grantSet {
+	grants := make(map[string]*grantSet)
+	for _, line := range lines {
+		m := showGrantsRegexp.FindStringSubmatch(line)
+		if m == nil || strings.Contains(m[1], "(") {
+			continue
+		}
+
+		database, table := splitGrantObject(m[2])
+		grant := apiv1alpha1.UserGrant{Database: database, Table: table}
+		key := grantObject(grant)
+		gs, ok := grants[key]
+		if !ok {
+			gs = &grantSet{database: database, table: table, privileges: make(map[string]struct{})}
+			grants[key] = gs
+		}
+
+		for _, p := range strings.Split(m[1], ",") {
+			p = strings.ToUpper(strings.TrimSpace(p))
+			if p == "USAGE" || p == "" {
+				continue
+			}
+			gs.privileges[p] = struct{}{}
+		}
+		gs.withGrantOption = gs.withGrantOption || m[3] != ""
+	}
+
+	return grants
+}
+
+// grantObjectRegexp matches database and table of the grant object, e.g. `meta`.*
+var grantObjectRegexp = regexp.MustCompile("^(\\*|`((?:[^`]|``)+)`)\\.(\\*|`((?:[^`]|``)+)`)$")
+
+// splitGrantObject returns unquoted database and table of the grant object
+func splitGrantObject(object string) (string, string) {
+	m := grantObjectRegexp.FindStringSubmatch(object)
+	if m == nil {
+		return "*", "*"
+	}
+
+	database, table := "*", "*"
+	if m[1] != "*" {
+		database = strings.ReplaceAll(m[2], "``", "`")
+	}
+	if m[3] != "*" {
+		table = strings.ReplaceAll(m[4], "``", "`")
+	}
+
+	return database, table
+}
+
+// privilegeDiff returns sorted privileges of a which are not in b
+func privilegeDiff(a, b map[string]struct{}) []string {
+	diff := make([]string, 0)
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			diff = append(diff, p)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func sortedKeys(m map[string]*grantSet) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
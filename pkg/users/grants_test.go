@@ -0,0 +1,209 @@
+package users
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+)
+
+// sorted returns sorted privileges of the grant set
+func (g *grantSet) sorted() []string {
+	privileges := make([]string, 0, len(g.privileges))
+	for p := range g.privileges {
+		privileges = append(privileges, p)
+	}
+	sort.Strings(privileges)
+	return privileges
+}
+
+func newGrantSet(database, table string, withGrantOption bool, privileges ...string) *grantSet {
+	gs := &grantSet{database: database, table: table, privileges: make(map[string]struct{}), withGrantOption: withGrantOption}
+	for _, p := range privileges {
+		gs.privileges[p] = struct{}{}
+	}
+	return gs
+}
+
+func TestParseGrants(t *testing.T) {
+	lines := []string{
+		"GRANT SELECT, RELOAD, PROCESS ON *.* TO `monitor`@`%`",
+		"GRANT BACKUP_ADMIN,SERVICE_CONNECTION_ADMIN ON *.* TO `monitor`@`%`",
+		"GRANT USAGE ON `meta`.* TO `monitor`@`%`",
+		"GRANT SELECT ON `performance_schema`.* TO `monitor`@`%` WITH GRANT OPTION",
+		"GRANT SELECT, INSERT ON `my``db`.`my``table` TO `monitor`@`%`",
+		"GRANT select ON `mysql`.`slave_master_info` TO `monitor`@`%`",
+		"GRANT SELECT (`id`, `name`), UPDATE (`name`) ON `app`.`users` TO `monitor`@`%`",
+		"GRANT EXECUTE ON PROCEDURE `app`.`cleanup` TO `monitor`@`%`",
+		"GRANT EXECUTE ON FUNCTION `app`.`f` TO `monitor`@`%`",
+		"GRANT `reader`@`%`,`writer`@`%` TO `monitor`@`%`",
+		"GRANT PROXY ON ``@`` TO `monitor`@`%` WITH GRANT OPTION",
+	}
+
+	expected := map[string]*grantSet{
+		"*.*":                         newGrantSet("*", "*", false, "BACKUP_ADMIN", "PROCESS", "RELOAD", "SELECT", "SERVICE_CONNECTION_ADMIN"),
+		"`meta`.*":                    newGrantSet("meta", "*", false),
+		"`performance_schema`.*":      newGrantSet("performance_schema", "*", true, "SELECT"),
+		"`my``db`.`my``table`":        newGrantSet("my`db", "my`table", false, "INSERT", "SELECT"),
+		"`mysql`.`slave_master_info`": newGrantSet("mysql", "slave_master_info", false, "SELECT"),
+	}
+
+	got := parseGrants(lines)
+	if !reflect.DeepEqual(sortedKeys(got), sortedKeys(expected)) {
+		t.Fatalf("expected grant objects %v, got %v", sortedKeys(expected), sortedKeys(got))
+	}
+	for key, want := range expected {
+		have := got[key]
+		if have.database != want.database || have.table != want.table {
+			t.Errorf("%s: expected %s.%s, got %s.%s", key, want.database, want.table, have.database, have.table)
+		}
+		if !reflect.DeepEqual(have.sorted(), want.sorted()) {
+			t.Errorf("%s: expected privileges %v, got %v", key, want.sorted(), have.sorted())
+		}
+		if have.withGrantOption != want.withGrantOption {
+			t.Errorf("%s: expected grant option %t, got %t", key, want.withGrantOption, have.withGrantOption)
+		}
+	}
+}
+
+func TestSplitGrantObject(t *testing.T) {
+	tests := []struct {
+		object   string
+		database string
+		table    string
+	}{
+		{"*.*", "*", "*"},
+		{"`meta`.*", "meta", "*"},
+		{"`meta`.`heartbeat`", "meta", "heartbeat"},
+		{"`a``b`.`c.d`", "a`b", "c.d"},
+		{"`a.b`.*", "a.b", "*"},
+	}
+
+	for _, tt := range tests {
+		database, table := splitGrantObject(tt.object)
+		if database != tt.database || table != tt.table {
+			t.Errorf("%s: expected %s and %s, got %s and %s", tt.object, tt.database, tt.table, database, table)
+		}
+		if got := grantObject(apiv1alpha1.UserGrant{Database: database, Table: table}); got != tt.object {
+			t.Errorf("%s: expected the same grant object, got %s", tt.object, got)
+		}
+	}
+}
+
+func TestGrantFixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		desired  []apiv1alpha1.UserGrant
+		current  []string
+		expected []grantFix
+	}{
+		{
+			name: "no drift",
+			desired: []apiv1alpha1.UserGrant{
+				globalGrant(false, "PROCESS", "select"),
+				selectGrant("meta", "*"),
+			},
+			current: []string{
+				"GRANT SELECT, PROCESS ON *.* TO `monitor`@`%`",
+				"GRANT SELECT ON `meta`.* TO `monitor`@`%`",
+			},
+			expected: []grantFix{},
+		},
+		{
+			name: "grants before revokes",
+			desired: []apiv1alpha1.UserGrant{
+				globalGrant(false, "PROCESS", "RELOAD"),
+				selectGrant("meta", "*"),
+			},
+			current: []string{
+				"GRANT PROCESS, SHUTDOWN ON *.* TO `monitor`@`%`",
+				"GRANT SELECT ON `app`.* TO `monitor`@`%`",
+			},
+			expected: []grantFix{
+				{grant: globalGrant(false, "RELOAD"), changed: []string{"RELOAD"}},
+				{grant: selectGrant("meta", "*"), changed: []string{"SELECT"}},
+				{revoke: true, grant: apiv1alpha1.UserGrant{Privileges: []apiv1alpha1.Privilege{"SHUTDOWN"}, Database: "*", Table: "*"}, changed: []string{"SHUTDOWN"}},
+				{revoke: true, grant: selectGrant("app", "*"), changed: []string{"SELECT"}},
+			},
+		},
+		{
+			name: "only grant option is revoked",
+			desired: []apiv1alpha1.UserGrant{
+				globalGrant(false, "PROCESS"),
+			},
+			current: []string{
+				"GRANT PROCESS ON *.* TO `monitor`@`%` WITH GRANT OPTION",
+			},
+			expected: []grantFix{
+				{revoke: true, grant: globalGrant(true, "USAGE"), changed: []string{"GRANT OPTION"}},
+			},
+		},
+		{
+			name: "grant option is revoked with extra privileges",
+			desired: []apiv1alpha1.UserGrant{
+				globalGrant(false, "PROCESS"),
+			},
+			current: []string{
+				"GRANT PROCESS, SUPER ON *.* TO `monitor`@`%` WITH GRANT OPTION",
+			},
+			expected: []grantFix{
+				{revoke: true, grant: globalGrant(true, "SUPER"), changed: []string{"SUPER", "GRANT OPTION"}},
+			},
+		},
+		{
+			name: "grant option is given to all privileges",
+			desired: []apiv1alpha1.UserGrant{
+				globalGrant(true, "PROCESS", "SELECT"),
+			},
+			current: []string{
+				"GRANT PROCESS, SELECT ON *.* TO `operator`@`%`",
+			},
+			expected: []grantFix{
+				{grant: globalGrant(true, "PROCESS", "SELECT"), changed: []string{"PROCESS", "SELECT", "GRANT OPTION"}},
+			},
+		},
+		{
+			name: "GRANT ALL of the operator before upgrade",
+			desired: []apiv1alpha1.UserGrant{
+				globalGrant(true, "PROCESS", "SELECT"),
+			},
+			current: []string{
+				"GRANT SELECT, PROCESS, SHUTDOWN, FILE ON *.* TO `operator`@`%` WITH GRANT OPTION",
+				"GRANT CONNECTION_ADMIN,CLONE_ADMIN ON *.* TO `operator`@`%` WITH GRANT OPTION",
+			},
+			expected: []grantFix{
+				{revoke: true, grant: globalGrant(false, "CLONE_ADMIN", "CONNECTION_ADMIN", "FILE", "SHUTDOWN"), changed: []string{"CLONE_ADMIN", "CONNECTION_ADMIN", "FILE", "SHUTDOWN"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := grantFixes(grantSets(tt.desired), parseGrants(tt.current))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected fixes %+v, got %+v", tt.expected, got)
+			}
+
+			revoked := false
+			for _, fix := range got {
+				if !fix.revoke && revoked {
+					t.Errorf("grant %+v after revoke", fix)
+				}
+				revoked = revoked || fix.revoke
+			}
+		})
+	}
+}
+
+func TestGrantFixDescribe(t *testing.T) {
+	fix := grantFix{revoke: true, grant: globalGrant(true, "USAGE"), changed: []string{"GRANT OPTION"}}
+	if got, expected := fix.describe("monitor@%"), "revoked GRANT OPTION on *.* from monitor@%"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	fix = grantFix{grant: selectGrant("meta", "*"), changed: []string{"SELECT"}}
+	if got, expected := fix.describe("monitor@%"), "granted SELECT on `meta`.* to monitor@%"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -1,49 +1,66 @@
 package users
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strings"
 
-	_ "github.com/go-sql-driver/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 
 	apiv1alpha1 "github.com/percona/percona-server-mysql-operator/api/v1alpha1"
+	"github.com/percona/percona-server-mysql-operator/pkg/db"
 	"github.com/percona/percona-server-mysql-operator/pkg/mysql"
 )
 
 type Manager interface {
-	UpdateUserPasswords(users []mysql.User) error
-	DiscardOldPasswords(users []mysql.User) error
+	UpdateUserPasswords(ctx context.Context, users []mysql.User) error
+	DiscardOldPasswords(ctx context.Context, users []mysql.User) error
+	EnsureUser(ctx context.Context, user mysql.User) error
+	DropUser(ctx context.Context, user mysql.User) error
+	SetResourceLimits(ctx context.Context, user mysql.User, limits apiv1alpha1.UserResourceLimits) error
+	Grant(ctx context.Context, user mysql.User, grant apiv1alpha1.UserGrant) error
+	Revoke(ctx context.Context, user mysql.User, grant apiv1alpha1.UserGrant) error
+	ReconcileGrants(ctx context.Context, user mysql.User, grants []apiv1alpha1.UserGrant) ([]string, error)
+	EnsureDatabase(ctx context.Context, name, charset, collation string) error
+	DropDatabase(ctx context.Context, name string) error
 	Close() error
 }
 
+// privilegeRegexp matches privileges which can be safely used in GRANT statements
+var privilegeRegexp = regexp.MustCompile(`^[A-Za-z_ ]+$`)
+
+// charsetRegexp matches names of character sets and collations
+var charsetRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+const (
+	errNoSuchGrant      = 1141
+	errNoSuchTableGrant = 1147
+)
+
 type dbImpl struct{ db *sql.DB }
 
-func NewManager(user apiv1alpha1.SystemUser, pass, host string, port int32) (Manager, error) {
-	connStr := fmt.Sprintf("%s:%s@tcp(%s:%d)/performance_schema?interpolateParams=true",
-		user, pass, host, port)
-	db, err := sql.Open("mysql", connStr)
+func NewManager(ctx context.Context, cfg db.Config) (Manager, error) {
+	conn, err := db.Open(ctx, cfg)
 	if err != nil {
-		return nil, errors.Wrap(err, "connect to MySQL")
-	}
-
-	if err := db.Ping(); err != nil {
-		return nil, errors.Wrap(err, "ping database")
+		return nil, err
 	}
 
-	return &dbImpl{db}, nil
+	return &dbImpl{conn}, nil
 }
 
 // UpdateUserPasswords updates user passwords but retains the current password using Dual Password feature of MySQL 8
-func (d *dbImpl) UpdateUserPasswords(users []mysql.User) error {
-	tx, err := d.db.Begin()
+func (d *dbImpl) UpdateUserPasswords(ctx context.Context, users []mysql.User) error {
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrap(err, "begin transaction")
 	}
 
 	for _, user := range users {
 		for _, host := range user.Hosts {
-			_, err = tx.Exec("ALTER USER ?@? IDENTIFIED BY ? RETAIN CURRENT PASSWORD", user.Username, host, user.Password)
+			_, err = tx.ExecContext(ctx, "ALTER USER ?@? IDENTIFIED BY ? RETAIN CURRENT PASSWORD", user.Username, host, user.Password)
 			if err != nil {
 				err = errors.Wrap(err, "alter user")
 
@@ -56,7 +73,7 @@ func (d *dbImpl) UpdateUserPasswords(users []mysql.User) error {
 		}
 	}
 
-	_, err = tx.Exec("FLUSH PRIVILEGES")
+	_, err = tx.ExecContext(ctx, "FLUSH PRIVILEGES")
 	if err != nil {
 		err = errors.Wrap(err, "flush privileges")
 
@@ -75,15 +92,15 @@ func (d *dbImpl) UpdateUserPasswords(users []mysql.User) error {
 }
 
 // DiscardOldPasswords discards old passwords of givens users
-func (d *dbImpl) DiscardOldPasswords(users []mysql.User) error {
-	tx, err := d.db.Begin()
+func (d *dbImpl) DiscardOldPasswords(ctx context.Context, users []mysql.User) error {
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrap(err, "begin transaction")
 	}
 
 	for _, user := range users {
 		for _, host := range user.Hosts {
-			_, err = tx.Exec("ALTER USER ?@? DISCARD OLD PASSWORD", user.Username, host)
+			_, err = tx.ExecContext(ctx, "ALTER USER ?@? DISCARD OLD PASSWORD", user.Username, host)
 			if err != nil {
 				err = errors.Wrap(err, "alter user")
 
@@ -96,7 +113,7 @@ func (d *dbImpl) DiscardOldPasswords(users []mysql.User) error {
 		}
 	}
 
-	_, err = tx.Exec("FLUSH PRIVILEGES")
+	_, err = tx.ExecContext(ctx, "FLUSH PRIVILEGES")
 	if err != nil {
 		err = errors.Wrap(err, "flush privileges")
 
@@ -114,6 +131,174 @@ func (d *dbImpl) DiscardOldPasswords(users []mysql.User) error {
 	return nil
 }
 
+// EnsureUser creates the user on all its hosts. Password of the existing user is reset.
+func (d *dbImpl) EnsureUser(ctx context.Context, user mysql.User) error {
+	for _, host := range user.Hosts {
+		_, err := d.db.ExecContext(ctx, "CREATE USER IF NOT EXISTS ?@? IDENTIFIED BY ?", user.Username, host, user.Password)
+		if err != nil {
+			return errors.Wrapf(err, "create user %s@%s", user.Username, host)
+		}
+
+		_, err = d.db.ExecContext(ctx, "ALTER USER ?@? IDENTIFIED BY ?", user.Username, host, user.Password)
+		if err != nil {
+			return errors.Wrapf(err, "set password of %s@%s", user.Username, host)
+		}
+	}
+
+	return nil
+}
+
+// DropUser drops the user from all its hosts
+func (d *dbImpl) DropUser(ctx context.Context, user mysql.User) error {
+	for _, host := range user.Hosts {
+		if _, err := d.db.ExecContext(ctx, "DROP USER IF EXISTS ?@?", user.Username, host); err != nil {
+			return errors.Wrapf(err, "drop user %s@%s", user.Username, host)
+		}
+	}
+
+	return nil
+}
+
+func (d *dbImpl) SetResourceLimits(ctx context.Context, user mysql.User, limits apiv1alpha1.UserResourceLimits) error {
+	for _, host := range user.Hosts {
+		_, err := d.db.ExecContext(ctx, `
+            ALTER USER ?@? WITH
+                MAX_QUERIES_PER_HOUR ?
+                MAX_UPDATES_PER_HOUR ?
+                MAX_CONNECTIONS_PER_HOUR ?
+                MAX_USER_CONNECTIONS ?
+            `, user.Username, host,
+			limits.MaxQueriesPerHour, limits.MaxUpdatesPerHour,
+			limits.MaxConnectionsPerHour, limits.MaxUserConnections)
+		if err != nil {
+			return errors.Wrapf(err, "set resource limits of %s@%s", user.Username, host)
+		}
+	}
+
+	return nil
+}
+
+// Grant grants privileges to the user on all its hosts
+func (d *dbImpl) Grant(ctx context.Context, user mysql.User, grant apiv1alpha1.UserGrant) error {
+	privileges, err := privilegeList(grant.Privileges)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf("GRANT %s ON %s TO ?@?", privileges, grantObject(grant))
+	if grant.WithGrantOption {
+		q += " WITH GRANT OPTION"
+	}
+
+	for _, host := range user.Hosts {
+		if _, err := d.db.ExecContext(ctx, q, user.Username, host); err != nil {
+			return errors.Wrapf(err, "grant %s on %s to %s@%s", privileges, grantObject(grant), user.Username, host)
+		}
+	}
+
+	return nil
+}
+
+// Revoke revokes privileges from the user on all its hosts.
+// Privileges which are not granted are ignored.
+func (d *dbImpl) Revoke(ctx context.Context, user mysql.User, grant apiv1alpha1.UserGrant) error {
+	privileges, err := privilegeList(grant.Privileges)
+	if err != nil {
+		return err
+	}
+	if grant.WithGrantOption {
+		privileges += ", GRANT OPTION"
+	}
+
+	q := fmt.Sprintf("REVOKE %s ON %s FROM ?@?", privileges, grantObject(grant))
+	for _, host := range user.Hosts {
+		_, err := d.db.ExecContext(ctx, q, user.Username, host)
+		var mErr *mysqldriver.MySQLError
+		if errors.As(err, &mErr) && (mErr.Number == errNoSuchGrant || mErr.Number == errNoSuchTableGrant) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "revoke %s on %s from %s@%s", privileges, grantObject(grant), user.Username, host)
+		}
+	}
+
+	return nil
+}
+
+// EnsureDatabase creates the database if it doesn't exist. Default character set
+// and collation of the database are changed if they are not empty and differ.
+func (d *dbImpl) EnsureDatabase(ctx context.Context, name, charset, collation string) error {
+	for _, v := range []string{charset, collation} {
+		if v != "" && !charsetRegexp.MatchString(v) {
+			return errors.Errorf("invalid character set or collation %q", v)
+		}
+	}
+
+	var currentCharset, currentCollation string
+	err := d.db.QueryRowContext(ctx, `
+        SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME
+        FROM information_schema.SCHEMATA
+        WHERE SCHEMA_NAME = ?
+        `, name).Scan(&currentCharset, &currentCollation)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrapf(err, "get character set of database %s", name)
+	}
+
+	options := ""
+	if charset != "" && charset != currentCharset {
+		options += " CHARACTER SET " + charset
+	}
+	if collation != "" && collation != currentCollation {
+		options += " COLLATE " + collation
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err := d.db.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+quoteIdentifier(name)+options)
+		return errors.Wrapf(err, "create database %s", name)
+	}
+
+	if options == "" {
+		return nil
+	}
+
+	_, err = d.db.ExecContext(ctx, "ALTER DATABASE "+quoteIdentifier(name)+options)
+	return errors.Wrapf(err, "alter database %s", name)
+}
+
+func (d *dbImpl) DropDatabase(ctx context.Context, name string) error {
+	_, err := d.db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+quoteIdentifier(name))
+	return errors.Wrapf(err, "drop database %s", name)
+}
+
+func privilegeList(privileges []apiv1alpha1.Privilege) (string, error) {
+	list := make([]string, 0, len(privileges))
+	for _, p := range privileges {
+		if !privilegeRegexp.MatchString(string(p)) {
+			return "", errors.Errorf("invalid privilege %q", p)
+		}
+		list = append(list, strings.ToUpper(string(p)))
+	}
+
+	return strings.Join(list, ", "), nil
+}
+
+// grantObject returns the quoted database object of the grant
+func grantObject(grant apiv1alpha1.UserGrant) string {
+	db, table := "*", "*"
+	if grant.Database != "*" {
+		db = quoteIdentifier(grant.Database)
+	}
+	if grant.Table != "" && grant.Table != "*" {
+		table = quoteIdentifier(grant.Table)
+	}
+
+	return db + "." + table
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
 func (d *dbImpl) Close() error {
 	return d.db.Close()
 }
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPClient returns a client which verifies server certificates with caCert.
+// If caCert is empty, http.DefaultClient with system root CAs is returned.
+// Clients are created per reconcile, so connections are not kept alive.
+func HTTPClient(caCert []byte) (*http.Client, error) {
+	if len(caCert) == 0 {
+		return http.DefaultClient, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("failed to parse CA certificate")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	transport.DisableKeepAlives = true
+
+	return &http.Client{Transport: transport}, nil
+}